@@ -0,0 +1,106 @@
+// Команда dedupserver запускает HTTP-сервис поиска почти дубликатов (см. пакет dedup).
+// Индекс загружается из снимка -snapshot, а если его нет – строится по корпусу -data
+// (файл articles_N.text) или начинается пустым. Снимок сохраняется каждые -interval и при остановке.
+//
+//	go run ./cmd/dedupserver -data minhash/data/articles_1000.text -snapshot index.mh -addr :8080
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"lab1/cmd/internal/shingleflag"
+	"lab1/dedup"
+	"lab1/evaluation"
+	"lab1/minhash"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "адрес HTTP-сервера")
+	snapshot := flag.String("snapshot", "", "файл снимка индекса (пусто – без снимков)")
+	interval := flag.Duration("interval", time.Minute, "период сохранения снимка")
+	data := flag.String("data", "", "корпус articles_N.text для построения индекса без снимка (пусто – пустой индекс)")
+	hashes := flag.Int("hashes", 128, "число хэш-функций")
+	bands := flag.Int("bands", 32, "число полос")
+	seed := flag.Int64("seed", 1, "зерно генератора перестановок")
+	shingles := flag.String("shingles", "words", "нарезка текста: fields, words или chars")
+	k := flag.Int("k", 1, "длина шингла для words и chars")
+	maxBody := flag.Int64("max-body", dedup.DefaultMaxBodyBytes, "наибольший размер тела запроса в байтах")
+	flag.Parse()
+
+	index, err := openIndex(*snapshot, *data, *hashes, *bands, *seed, *shingles, *k)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := dedup.NewServer(index, *snapshot)
+	server.MaxBodyBytes = *maxBody
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	snapshots := make(chan struct{})
+	go func() {
+		defer close(snapshots)
+		if *snapshot != "" {
+			server.SnapshotEvery(ctx, *interval)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-snapshots
+}
+
+// openIndex загружает индекс из снимка или строит его по корпусу.
+func openIndex(snapshot, data string, hashes, bands int, seed int64, shingles string, k int) (*minhash.MinHash, error) {
+	tok, err := shingleflag.Tokenizer(shingles, k)
+	if err != nil {
+		return nil, err
+	}
+	cfg := minhash.Config{HashFunctions: hashes, Bands: bands, Seed: seed, Tokenizer: tok}
+
+	if snapshot != "" {
+		index, err := minhash.LoadFile(snapshot, cfg)
+		if err == nil {
+			log.Printf("loaded %d documents from %s", index.Len(), snapshot)
+			return index, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if data == "" {
+		log.Printf("no snapshot found, starting with an empty index")
+		return minhash.NewMinHashFromTexts(cfg, nil)
+	}
+	corpus, err := evaluation.ReadCorpus(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.IDs = corpus.IDs
+	index, err := minhash.NewMinHashFromTexts(cfg, corpus.Texts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("indexed %d documents from %s", index.Len(), data)
+	return index, nil
+}
@@ -0,0 +1,23 @@
+// Package shingleflag разбирает общий для команд флаг -shingles.
+package shingleflag
+
+import (
+	"fmt"
+
+	"lab1/tokenizer"
+)
+
+// Tokenizer строит токенизатор по значению флага -shingles: "words" и "chars" – шинглы
+// из k слов или символов с приведением регистра и без пунктуации, "fields" – nil,
+// то есть разбиение по пробелам без нормализации.
+func Tokenizer(mode string, k int) (*tokenizer.Tokenizer, error) {
+	switch mode {
+	case "fields":
+		return nil, nil
+	case "words":
+		return &tokenizer.Tokenizer{Mode: tokenizer.Words, K: k, FoldCase: true, StripPunctuation: true}, nil
+	case "chars":
+		return &tokenizer.Tokenizer{Mode: tokenizer.Chars, K: k, FoldCase: true, StripPunctuation: true}, nil
+	}
+	return nil, fmt.Errorf("unknown shingles mode %q", mode)
+}
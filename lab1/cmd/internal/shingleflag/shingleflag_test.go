@@ -0,0 +1,20 @@
+package shingleflag
+
+import (
+	"testing"
+
+	"lab1/tokenizer"
+)
+
+func TestTokenizer(t *testing.T) {
+	if tok, err := Tokenizer("fields", 3); tok != nil || err != nil {
+		t.Errorf("Tokenizer(fields) = (%v, %v), want (nil, nil)", tok, err)
+	}
+	tok, err := Tokenizer("chars", 5)
+	if err != nil || tok.Mode != tokenizer.Chars || tok.K != 5 || !tok.FoldCase || !tok.StripPunctuation {
+		t.Errorf("Tokenizer(chars, 5) = (%+v, %v)", tok, err)
+	}
+	if _, err := Tokenizer("lines", 1); err == nil {
+		t.Error("Tokenizer accepted an unknown mode")
+	}
+}
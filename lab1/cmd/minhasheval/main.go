@@ -0,0 +1,274 @@
+// Команда minhasheval прогоняет MinHash и SimHash по корпусам articles_N.text с сеткой параметров
+// (число хэш-функций и полос для MinHash, расстояние Хэмминга для SimHash)
+// и сравнивает найденные пары с articles_N.test.
+//
+//	go run ./cmd/minhasheval -data minhash/data -hashes 50,100,200 -bands 5,10,20 -format csv -out results.csv
+//	go run ./cmd/minhasheval -algorithms minhash,simhash -distances 1,3,6 -shingles words -k 2
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"lab1/cmd/internal/shingleflag"
+	"lab1/evaluation"
+	"lab1/minhash"
+	"lab1/simhash"
+	"lab1/tokenizer"
+)
+
+func main() {
+	dataDir := flag.String("data", "minhash/data", "каталог с файлами articles_N.text и articles_N.test")
+	hashesFlag := flag.String("hashes", "50,100,200", "список чисел хэш-функций через запятую")
+	bandsFlag := flag.String("bands", "5,10,20,25", "список чисел полос через запятую")
+	algorithmsFlag := flag.String("algorithms", "minhash", "алгоритмы через запятую: minhash, simhash")
+	distancesFlag := flag.String("distances", "1,3,6", "список расстояний Хэмминга для SimHash через запятую")
+	threshold := flag.Float64("threshold", 0.8, "порог оценки сходства для найденных пар")
+	seed := flag.Int64("seed", 1, "зерно генератора перестановок")
+	shingles := flag.String("shingles", "fields", "нарезка текста: fields, words или chars")
+	k := flag.Int("k", 1, "длина шингла для words и chars")
+	format := flag.String("format", "csv", "формат результата: csv или json")
+	out := flag.String("out", "", "файл результата (по умолчанию stdout)")
+	flag.Parse()
+
+	hashes, err := parseInts(*hashesFlag)
+	if err != nil {
+		log.Fatalf("hashes: %v", err)
+	}
+	bands, err := parseInts(*bandsFlag)
+	if err != nil {
+		log.Fatalf("bands: %v", err)
+	}
+	distances, err := parseInts(*distancesFlag)
+	if err != nil {
+		log.Fatalf("distances: %v", err)
+	}
+	algorithms := strings.Split(*algorithmsFlag, ",")
+	for _, algorithm := range algorithms {
+		if algorithm != "minhash" && algorithm != "simhash" {
+			log.Fatalf("unknown algorithm %q", algorithm)
+		}
+	}
+	var grid [][2]int
+	for _, algorithm := range algorithms {
+		if algorithm == "minhash" {
+			if grid, err = minhashGrid(hashes, bands); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	tok, err := shingleflag.Tokenizer(*shingles, *k)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	corpora, err := filepath.Glob(filepath.Join(*dataDir, "articles_*.text"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(corpora, func(i, j int) bool { return corpusSize(corpora[i]) < corpusSize(corpora[j]) })
+
+	var results []evaluation.Result
+	for _, path := range corpora {
+		name := strings.TrimSuffix(filepath.Base(path), ".text")
+		corpus, err := evaluation.ReadCorpus(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		truth, err := evaluation.ReadGroundTruth(strings.TrimSuffix(path, ".text") + ".test")
+		if err != nil {
+			log.Printf("skip %s: %v", name, err)
+			continue
+		}
+
+		for _, algorithm := range algorithms {
+			switch algorithm {
+			case "minhash":
+				for _, pair := range grid {
+					h, b := pair[0], pair[1]
+					result, err := run(corpus, truth, tok, h, b, *threshold, *seed)
+					if err != nil {
+						log.Fatalf("minhash: %v", err)
+					}
+					result.Corpus = name
+					log.Printf("%s minhash h=%d b=%d: precision=%.3f recall=%.3f f1=%.3f candidates=%d time=%v",
+						name, h, b, result.Precision, result.Recall, result.F1, result.Candidates, result.WallTime)
+					results = append(results, result)
+				}
+			case "simhash":
+				for _, d := range distances {
+					result, err := runSimHash(corpus, truth, tok, d)
+					if err != nil {
+						log.Fatalf("simhash: %v", err)
+					}
+					result.Corpus = name
+					log.Printf("%s simhash k=%d: precision=%.3f recall=%.3f f1=%.3f candidates=%d time=%v",
+						name, d, result.Precision, result.Recall, result.F1, result.Candidates, result.WallTime)
+					results = append(results, result)
+				}
+			}
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "csv":
+		err = evaluation.WriteCSV(w, results)
+	case "json":
+		err = evaluation.WriteJSON(w, results)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run строит MinHash с h хэш-функциями и b полосами и оценивает найденные пары.
+func run(corpus *evaluation.Corpus, truth map[[2]string]bool, tok *tokenizer.Tokenizer, h, b int, threshold float64, seed int64) (evaluation.Result, error) {
+	start := time.Now()
+
+	cfg := minhash.Config{HashFunctions: h, Bands: b, Seed: seed, IDs: corpus.IDs, Tokenizer: tok}
+	var mh *minhash.MinHash
+	var err error
+	if tok == nil {
+		sets := make([][]string, len(corpus.Texts))
+		for i, text := range corpus.Texts {
+			sets[i] = strings.Fields(text)
+		}
+		mh, err = minhash.NewMinHash(cfg, sets)
+	} else {
+		mh, err = minhash.NewMinHashFromTexts(cfg, corpus.Texts)
+	}
+	if err != nil {
+		return evaluation.Result{}, err
+	}
+
+	candidates := mh.SimilarPairs()
+	var found [][2]string
+	for _, pair := range candidates {
+		if pair.Similarity >= threshold {
+			found = append(found, [2]string{pair.A, pair.B})
+		}
+	}
+	elapsed := time.Since(start)
+
+	return evaluation.Result{
+		Algorithm:  "minhash",
+		HashCount:  h,
+		Bands:      b,
+		Rows:       h / b,
+		Threshold:  threshold,
+		Candidates: len(candidates),
+		WallTime:   elapsed,
+		Metrics:    evaluation.Score(found, truth),
+	}, nil
+}
+
+// runSimHash строит 64-битные отпечатки SimHash и индекс для расстояния Хэмминга d
+// и оценивает все пары в пределах d.
+func runSimHash(corpus *evaluation.Corpus, truth map[[2]string]bool, tok *tokenizer.Tokenizer, d int) (evaluation.Result, error) {
+	start := time.Now()
+
+	idx, err := simhash.NewIndex(d)
+	if err != nil {
+		return evaluation.Result{}, err
+	}
+	for i, text := range corpus.Texts {
+		var fp uint64
+		if tok == nil {
+			fp = simhash.FromTokens(strings.Fields(text))
+		} else {
+			fp = simhash.FromText(text, tok)
+		}
+		if err := idx.Add(corpus.IDs[i], fp); err != nil {
+			return evaluation.Result{}, err
+		}
+	}
+
+	pairs := idx.Pairs()
+	found := make([][2]string, len(pairs))
+	for i, pair := range pairs {
+		found[i] = [2]string{pair.A, pair.B}
+	}
+	elapsed := time.Since(start)
+
+	return evaluation.Result{
+		Algorithm:   "simhash",
+		HashCount:   simhash.Size,
+		Bands:       d + 1,
+		MaxDistance: d,
+		Candidates:  len(pairs),
+		WallTime:    elapsed,
+		Metrics:     evaluation.Score(found, truth),
+	}, nil
+}
+
+// minhashGrid возвращает пары (число хэш-функций, число полос) из сетки, в которых
+// хэш-функции делятся на полосы поровну. Остальные пары пропускаются с сообщением в лог;
+// если какое-то значение из hashes или bands не входит ни в одну пару, возвращается ошибка.
+func minhashGrid(hashes, bands []int) ([][2]int, error) {
+	var grid [][2]int
+	usedHashes := make(map[int]bool)
+	usedBands := make(map[int]bool)
+	for _, h := range hashes {
+		for _, b := range bands {
+			if h <= 0 || b <= 0 {
+				return nil, fmt.Errorf("hash and band counts must be positive, got h=%d b=%d", h, b)
+			}
+			if b > h || h%b != 0 {
+				log.Printf("skip minhash h=%d b=%d: %d hash functions do not split into %d equal bands", h, b, h, b)
+				continue
+			}
+			grid = append(grid, [2]int{h, b})
+			usedHashes[h], usedBands[b] = true, true
+		}
+	}
+	for _, h := range hashes {
+		if !usedHashes[h] {
+			return nil, fmt.Errorf("none of bands %v divides %d hash functions", bands, h)
+		}
+	}
+	for _, b := range bands {
+		if !usedBands[b] {
+			return nil, fmt.Errorf("%d bands divide none of hash counts %v", b, hashes)
+		}
+	}
+	return grid, nil
+}
+
+func parseInts(list string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// corpusSize извлекает N из имени articles_N.text для сортировки корпусов по размеру.
+func corpusSize(path string) int {
+	name := strings.TrimSuffix(filepath.Base(path), ".text")
+	size, _ := strconv.Atoi(strings.TrimPrefix(name, "articles_"))
+	return size
+}
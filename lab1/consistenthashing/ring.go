@@ -0,0 +1,87 @@
+package consistenthashing
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// Ring – кольцо консистентного хэширования с виртуальными узлами.
+// Каждый шард занимает VirtualNodes точек на кольце, ключ принадлежит
+// первому по часовой стрелке виртуальному узлу.
+type Ring struct {
+	VirtualNodes int
+	points       []uint64
+	owners       map[uint64]string
+}
+
+// NewRing создаёт пустое кольцо с virtualNodes виртуальными узлами на шард.
+func NewRing(virtualNodes int) *Ring {
+	if virtualNodes < 1 {
+		virtualNodes = 1
+	}
+	return &Ring{
+		VirtualNodes: virtualNodes,
+		owners:       make(map[uint64]string),
+	}
+}
+
+// ringHash – 64-битный FNV-1a, которым размещаются и ключи, и виртуальные узлы.
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Add добавляет шард на кольцо. Повторное добавление ничего не меняет.
+func (r *Ring) Add(shard string) {
+	for i := 0; i < r.VirtualNodes; i++ {
+		point := ringHash(fmt.Sprintf("%s#%d", shard, i))
+		if _, exists := r.owners[point]; exists {
+			continue
+		}
+		r.owners[point] = shard
+		r.points = append(r.points, point)
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Remove убирает с кольца все виртуальные узлы шарда.
+func (r *Ring) Remove(shard string) {
+	points := r.points[:0]
+	for _, point := range r.points {
+		if r.owners[point] == shard {
+			delete(r.owners, point)
+			continue
+		}
+		points = append(points, point)
+	}
+	r.points = points
+}
+
+// Owner возвращает шард, которому принадлежит ключ. Для пустого кольца ok == false.
+func (r *Ring) Owner(key string) (shard string, ok bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	h := ringHash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]], true
+}
+
+// Shards возвращает отсортированный список шардов на кольце.
+func (r *Ring) Shards() []string {
+	seen := make(map[string]bool)
+	var shards []string
+	for _, shard := range r.owners {
+		if !seen[shard] {
+			seen[shard] = true
+			shards = append(shards, shard)
+		}
+	}
+	sort.Strings(shards)
+	return shards
+}
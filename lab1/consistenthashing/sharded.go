@@ -0,0 +1,157 @@
+package consistenthashing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	extendablehash "lab1/extendiblehashing"
+)
+
+var (
+	ErrShardExists   = errors.New("shard already exists")
+	ErrShardNotFound = errors.New("shard not found")
+	ErrLastShard     = errors.New("cannot remove the last shard")
+)
+
+// RebalanceReport описывает результат добавления или удаления шарда.
+type RebalanceReport struct {
+	// Moved – сколько ключей было перенесено между шардами.
+	Moved int
+	// Total – сколько ключей хранилось во всех шардах на момент перебалансировки.
+	Total int
+	// MovedFrom – сколько ключей ушло из каждого шарда.
+	MovedFrom map[string]int
+	// MovedTo – сколько ключей пришло в каждый шард.
+	MovedTo map[string]int
+}
+
+// ShardedHashTable – key-value слой, распределяющий ключи по независимым
+// ExtendableHashTable через кольцо консистентного хэширования.
+// Каждый шард хранит бакеты в собственном подкаталоге basePath.
+type ShardedHashTable struct {
+	Ring     *Ring
+	Shards   map[string]*extendablehash.ExtendableHashTable
+	basePath string
+}
+
+// NewShardedHashTable создаёт шардированную таблицу с заданными шардами.
+func NewShardedHashTable(basePath string, virtualNodes int, shards ...string) (*ShardedHashTable, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("at least one shard is required")
+	}
+	sht := &ShardedHashTable{
+		Ring:     NewRing(virtualNodes),
+		Shards:   make(map[string]*extendablehash.ExtendableHashTable),
+		basePath: basePath,
+	}
+	for _, shard := range shards {
+		if _, exists := sht.Shards[shard]; exists {
+			return nil, ErrShardExists
+		}
+		sht.Shards[shard] = extendablehash.NewExtendableHashTableAt(sht.shardPath(shard))
+		sht.Ring.Add(shard)
+	}
+	return sht, nil
+}
+
+func (sht *ShardedHashTable) shardPath(shard string) string {
+	return filepath.Join(sht.basePath, shard)
+}
+
+// shardFor возвращает хранилище, отвечающее за ключ.
+func (sht *ShardedHashTable) shardFor(key string) *extendablehash.ExtendableHashTable {
+	shard, _ := sht.Ring.Owner(key)
+	return sht.Shards[shard]
+}
+
+// Insert вставляет пару ключ-значение в шард-владелец ключа.
+func (sht *ShardedHashTable) Insert(key string, value interface{}) {
+	sht.shardFor(key).Insert(key, value)
+}
+
+// Get возвращает значение по ключу из шарда-владельца.
+func (sht *ShardedHashTable) Get(key string) (interface{}, bool) {
+	return sht.shardFor(key).Get(key)
+}
+
+// Delete удаляет ключ и возвращает true, если он был найден.
+func (sht *ShardedHashTable) Delete(key string) bool {
+	return sht.shardFor(key).Delete(key)
+}
+
+// Len возвращает общее количество ключей во всех шардах.
+func (sht *ShardedHashTable) Len() int {
+	total := 0
+	for _, store := range sht.Shards {
+		total += store.Len()
+	}
+	return total
+}
+
+// AddShard добавляет шард на кольцо и переносит в него только те ключи,
+// владельцем которых он стал.
+func (sht *ShardedHashTable) AddShard(shard string) (RebalanceReport, error) {
+	if _, exists := sht.Shards[shard]; exists {
+		return RebalanceReport{}, ErrShardExists
+	}
+	sht.Shards[shard] = extendablehash.NewExtendableHashTableAt(sht.shardPath(shard))
+	sht.Ring.Add(shard)
+
+	report := newRebalanceReport()
+	for name, store := range sht.Shards {
+		if name == shard {
+			continue
+		}
+		for key, value := range store.Items() {
+			report.Total++
+			if owner, _ := sht.Ring.Owner(key); owner != name {
+				sht.move(&report, key, value, name, owner)
+			}
+		}
+	}
+	return report, nil
+}
+
+// RemoveShard убирает шард с кольца, переносит его ключи к новым владельцам
+// и удаляет файлы шарда. Ключи остальных шардов не трогаются.
+func (sht *ShardedHashTable) RemoveShard(shard string) (RebalanceReport, error) {
+	removed, exists := sht.Shards[shard]
+	if !exists {
+		return RebalanceReport{}, ErrShardNotFound
+	}
+	if len(sht.Shards) == 1 {
+		return RebalanceReport{}, ErrLastShard
+	}
+	sht.Ring.Remove(shard)
+
+	report := newRebalanceReport()
+	for name, store := range sht.Shards {
+		if name != shard {
+			report.Total += store.Len()
+		}
+	}
+	for key, value := range removed.Items() {
+		report.Total++
+		owner, _ := sht.Ring.Owner(key)
+		sht.move(&report, key, value, shard, owner)
+	}
+	delete(sht.Shards, shard)
+	return report, os.RemoveAll(sht.shardPath(shard))
+}
+
+// move переносит ключ из шарда from в шард to и учитывает перенос в отчёте.
+func (sht *ShardedHashTable) move(report *RebalanceReport, key string, value interface{}, from, to string) {
+	sht.Shards[to].Insert(key, value)
+	sht.Shards[from].Delete(key)
+	report.Moved++
+	report.MovedFrom[from]++
+	report.MovedTo[to]++
+}
+
+func newRebalanceReport() RebalanceReport {
+	return RebalanceReport{
+		MovedFrom: make(map[string]int),
+		MovedTo:   make(map[string]int),
+	}
+}
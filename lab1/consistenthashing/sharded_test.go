@@ -0,0 +1,135 @@
+package consistenthashing
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	t.Run("empty ring", func(t *testing.T) {
+		if _, ok := NewRing(10).Owner("key"); ok {
+			t.Error("Expected empty ring to have no owner")
+		}
+	})
+
+	t.Run("stable owners", func(t *testing.T) {
+		ring := NewRing(50)
+		ring.Add("a")
+		ring.Add("b")
+		ring.Add("c")
+
+		owners := make(map[string]string)
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("key%d", i)
+			owners[key], _ = ring.Owner(key)
+		}
+
+		// Добавление шарда может перевести ключ только на новый шард.
+		ring.Add("d")
+		for key, before := range owners {
+			after, _ := ring.Owner(key)
+			if after != before && after != "d" {
+				t.Fatalf("Key %s moved from %s to %s", key, before, after)
+			}
+		}
+
+		// Удаление шарда возвращает прежних владельцев.
+		ring.Remove("d")
+		for key, before := range owners {
+			if after, _ := ring.Owner(key); after != before {
+				t.Fatalf("Key %s: expected owner %s, got %s", key, before, after)
+			}
+		}
+	})
+}
+
+func TestShardedHashTable(t *testing.T) {
+	const size = 600
+
+	sht, err := NewShardedHashTable(t.TempDir(), 64, "shard-0", "shard-1", "shard-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make(map[string]string, size)
+	for i := 0; i < size; i++ {
+		key := fmt.Sprintf("key%09d", i)
+		data[key] = fmt.Sprintf("value%d", i)
+		sht.Insert(key, data[key])
+	}
+
+	checkAll := func(t *testing.T) {
+		if got := sht.Len(); got != size {
+			t.Fatalf("Expected %d keys, got %d", size, got)
+		}
+		for key, value := range data {
+			if got, exists := sht.Get(key); !exists || got != value {
+				t.Fatalf("Key %v: expected %v, got %v", key, value, got)
+			}
+		}
+	}
+
+	t.Run("routing", checkAll)
+
+	t.Run("add shard", func(t *testing.T) {
+		before := make(map[string]string, size)
+		for key := range data {
+			before[key], _ = sht.Ring.Owner(key)
+		}
+
+		report, err := sht.AddShard("shard-3")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedMoved := 0
+		for key, owner := range before {
+			if now, _ := sht.Ring.Owner(key); now != owner {
+				expectedMoved++
+			}
+		}
+		if report.Moved != expectedMoved || report.MovedTo["shard-3"] != expectedMoved {
+			t.Errorf("Expected %d moved keys, report: %+v", expectedMoved, report)
+		}
+		if report.Total != size {
+			t.Errorf("Expected total %d, got %d", size, report.Total)
+		}
+		if report.Moved == 0 || report.Moved == size {
+			t.Errorf("Unexpected number of moved keys: %d", report.Moved)
+		}
+		t.Logf("Add shard: moved %d of %d keys", report.Moved, report.Total)
+		checkAll(t)
+	})
+
+	t.Run("remove shard", func(t *testing.T) {
+		owned := sht.Shards["shard-1"].Len()
+
+		report, err := sht.RemoveShard("shard-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Moved != owned || report.MovedFrom["shard-1"] != owned {
+			t.Errorf("Expected %d moved keys, report: %+v", owned, report)
+		}
+		t.Logf("Remove shard: moved %d of %d keys", report.Moved, report.Total)
+		checkAll(t)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := sht.AddShard("shard-0"); err != ErrShardExists {
+			t.Errorf("Expected ErrShardExists, got %v", err)
+		}
+		if _, err := sht.RemoveShard("shard-1"); err != ErrShardNotFound {
+			t.Errorf("Expected ErrShardNotFound, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if !sht.Delete("key000000000") {
+			t.Error("Expected key to be deleted")
+		}
+		if sht.Delete("key000000000") {
+			t.Error("Expected second delete to report a missing key")
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"lab1/minhash"
+)
+
+// DefaultThreshold – порог сходства, если запрос его не задаёт.
+const DefaultThreshold = 0.8
+
+// DefaultMaxBodyBytes – ограничение размера тела запроса по умолчанию.
+const DefaultMaxBodyBytes = 1 << 20
+
+// ErrSnapshotsDisabled – сервер создан без пути для снимков.
+var ErrSnapshotsDisabled = errors.New("snapshots are disabled")
+
+// Server – HTTP-сервис поиска почти дубликатов поверх инкрементального индекса MinHash.
+//
+//	POST   /documents                {"id": "...", "text": "..."} – добавить документ, в ответе его дубликаты
+//	DELETE /documents/{id}           – удалить документ
+//	POST   /query                    {"text": "...", "threshold": 0.8, "k": 10} – найти дубликаты текста
+//	GET    /clusters?threshold=0.8   – группы дубликатов
+//	POST   /snapshot                 – сохранить снимок индекса
+//
+// Индекс защищён RWMutex: поиск выполняется параллельно, изменения – по одному.
+type Server struct {
+	// MaxBodyBytes ограничивает размер тела POST /documents и POST /query; на более
+	// длинные тела сервер отвечает 413. NewServer ставит DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	mu           sync.RWMutex
+	index        *minhash.MinHash
+	snapshotPath string
+	mux          *http.ServeMux
+}
+
+// NewServer создаёт сервис над index. Если snapshotPath не пуст, Snapshot
+// и SnapshotEvery сохраняют индекс в этот файл.
+func NewServer(index *minhash.MinHash, snapshotPath string) *Server {
+	s := &Server{MaxBodyBytes: DefaultMaxBodyBytes, index: index, snapshotPath: snapshotPath, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /documents", s.handleAdd)
+	s.mux.HandleFunc("DELETE /documents/{id}", s.handleRemove)
+	s.mux.HandleFunc("POST /query", s.handleQuery)
+	s.mux.HandleFunc("GET /clusters", s.handleClusters)
+	s.mux.HandleFunc("POST /snapshot", s.handleSnapshot)
+	return s
+}
+
+// ServeHTTP реализует http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Document – тело запроса на добавление документа.
+type Document struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// AddResponse – ответ на добавление: документы, похожие на добавленный.
+type AddResponse struct {
+	ID         string                `json:"id"`
+	Duplicates []minhash.QueryResult `json:"duplicates"`
+}
+
+// QueryRequest – тело запроса поиска. Threshold – порог сходства из [0, 1], K > 0 ограничивает
+// число результатов. Если задано только K, возвращаются K лучших без порога; если ни то ни другое –
+// все результаты с порогом DefaultThreshold.
+type QueryRequest struct {
+	Text      string   `json:"text"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	K         int      `json:"k,omitempty"`
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	var doc Document
+	if !s.decodeBody(w, r, &doc) {
+		return
+	}
+	if doc.ID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("id is required"))
+		return
+	}
+	threshold, err := thresholdParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	duplicates := s.index.QueryText(doc.Text, threshold)
+	err = s.index.AddText(doc.ID, doc.Text)
+	s.mu.Unlock()
+
+	if errors.Is(err, minhash.ErrDocumentExists) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, AddResponse{ID: doc.ID, Duplicates: nonNil(duplicates)})
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	err := s.index.Remove(r.PathValue("id"))
+	s.mu.Unlock()
+
+	if errors.Is(err, minhash.ErrDocumentNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req QueryRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+	threshold := DefaultThreshold
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+		if err := checkThreshold(threshold); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if req.K < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("k must not be negative"))
+		return
+	}
+
+	s.mu.RLock()
+	var result []minhash.QueryResult
+	if req.K > 0 && req.Threshold == nil {
+		result = s.index.QueryTextTopK(req.Text, req.K)
+	} else {
+		// Результаты отсортированы по убыванию сходства: первые K из прошедших порог – лучшие.
+		result = s.index.QueryText(req.Text, threshold)
+		if req.K > 0 && len(result) > req.K {
+			result = result[:req.K]
+		}
+	}
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, nonNil(result))
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	threshold, err := thresholdParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.RLock()
+	clusters := s.index.Clusters(threshold)
+	s.mu.RUnlock()
+
+	if clusters == nil {
+		clusters = []minhash.Cluster{}
+	}
+	writeJSON(w, http.StatusOK, clusters)
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	err := s.Snapshot()
+	if errors.Is(err, ErrSnapshotsDisabled) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Snapshot атомарно сохраняет индекс в snapshotPath.
+func (s *Server) Snapshot() error {
+	if s.snapshotPath == "" {
+		return ErrSnapshotsDisabled
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index.SaveFile(s.snapshotPath)
+}
+
+// SnapshotEvery сохраняет снимок индекса каждые interval, пока не отменён ctx,
+// и делает последний снимок при отмене. Ошибки сохранения пишутся в лог.
+func (s *Server) SnapshotEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Snapshot(); err != nil {
+				log.Printf("snapshot: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				log.Printf("snapshot: %v", err)
+			}
+		}
+	}
+}
+
+// decodeBody читает JSON из тела запроса не длиннее MaxBodyBytes. При ошибке
+// она отвечает клиенту сама (413 или 400) и возвращает false.
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, value interface{}) bool {
+	body := http.MaxBytesReader(w, r.Body, s.MaxBodyBytes)
+	err := json.NewDecoder(body).Decode(value)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return false
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// thresholdParam читает порог из параметра threshold строки запроса.
+func thresholdParam(r *http.Request) (float64, error) {
+	value := r.URL.Query().Get("threshold")
+	if value == "" {
+		return DefaultThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errThreshold
+	}
+	return threshold, checkThreshold(threshold)
+}
+
+var errThreshold = errors.New("threshold must be a number in [0, 1]")
+
+// checkThreshold проверяет, что порог лежит в [0, 1].
+func checkThreshold(threshold float64) error {
+	if !(threshold >= 0 && threshold <= 1) {
+		return errThreshold
+	}
+	return nil
+}
+
+// nonNil заменяет nil пустым срезом, чтобы в JSON был [] вместо null.
+func nonNil(result []minhash.QueryResult) []minhash.QueryResult {
+	if result == nil {
+		return []minhash.QueryResult{}
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,220 @@
+package dedup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lab1/minhash"
+	"lab1/tokenizer"
+)
+
+const (
+	article = "Nicolas Sarkozy announced on Tuesday that he would visit China next month " +
+		"to discuss trade relations and the situation in Tibet with the Chinese leadership"
+	edited = "Nicolas Sarkozy announced on Tuesday that he would visit China next month " +
+		"to discuss trade relations and the situation in Tibet with Chinese leaders"
+	other = "The stock market fell sharply on Monday as investors worried about rising oil prices"
+)
+
+func newTestServer(t *testing.T, snapshotPath string) (*Server, *httptest.Server) {
+	t.Helper()
+	tok := &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 1, FoldCase: true, StripPunctuation: true}
+	index, err := minhash.NewMinHashFromTexts(minhash.Config{
+		HashFunctions: 128,
+		Bands:         32,
+		Seed:          1,
+		IDs:           []string{"article", "other"},
+		Tokenizer:     tok,
+	}, []string{article, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(index, snapshotPath)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return server, ts
+}
+
+func do(t *testing.T, method, url string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reader).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	_, ts := newTestServer(t, "")
+
+	var added AddResponse
+	if status := do(t, "POST", ts.URL+"/documents", Document{ID: "edited", Text: edited}, &added); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	if len(added.Duplicates) != 1 || added.Duplicates[0].ID != "article" {
+		t.Errorf("Expected duplicate article, got %+v", added.Duplicates)
+	}
+	if status := do(t, "POST", ts.URL+"/documents", Document{ID: "edited", Text: edited}, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 for existing document, got %d", status)
+	}
+	if status := do(t, "POST", ts.URL+"/documents", Document{Text: edited}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 without id, got %d", status)
+	}
+
+	var result []minhash.QueryResult
+	if status := do(t, "POST", ts.URL+"/query", QueryRequest{Text: article}, &result); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if len(result) != 2 || result[0].ID != "article" || result[0].Similarity != 1 {
+		t.Errorf("Expected article and edited, got %+v", result)
+	}
+	do(t, "POST", ts.URL+"/query", QueryRequest{Text: article, K: 1}, &result)
+	if len(result) != 1 {
+		t.Errorf("Expected 1 result with k=1, got %+v", result)
+	}
+	// С порогом и k: k лучших среди прошедших порог.
+	high, low := 0.99, 0.5
+	do(t, "POST", ts.URL+"/query", QueryRequest{Text: edited, Threshold: &high, K: 2}, &result)
+	if len(result) != 1 || result[0].ID != "edited" {
+		t.Errorf("Expected only edited above 0.99 with k=2, got %+v", result)
+	}
+	do(t, "POST", ts.URL+"/query", QueryRequest{Text: edited, Threshold: &low, K: 1}, &result)
+	if len(result) != 1 || result[0].ID != "edited" {
+		t.Errorf("Expected the best match edited with k=1, got %+v", result)
+	}
+	for _, threshold := range []float64{-3, 7} {
+		if status := do(t, "POST", ts.URL+"/query", QueryRequest{Text: article, Threshold: &threshold}, nil); status != http.StatusBadRequest {
+			t.Errorf("Expected 400 for threshold %v, got %d", threshold, status)
+		}
+	}
+	if status := do(t, "POST", ts.URL+"/query", QueryRequest{Text: article, K: -1}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for negative k, got %d", status)
+	}
+
+	var clusters []minhash.Cluster
+	if status := do(t, "GET", ts.URL+"/clusters?threshold=0.7", nil, &clusters); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if len(clusters) != 1 || clusters[0].Representative != "article" || len(clusters[0].Members) != 2 {
+		t.Errorf("Expected cluster {article, edited}, got %+v", clusters)
+	}
+	if status := do(t, "GET", ts.URL+"/clusters?threshold=abc", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid threshold, got %d", status)
+	}
+
+	if status := do(t, "DELETE", ts.URL+"/documents/edited", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	if status := do(t, "DELETE", ts.URL+"/documents/edited", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for removed document, got %d", status)
+	}
+	do(t, "GET", ts.URL+"/clusters?threshold=0.7", nil, &clusters)
+	if len(clusters) != 0 {
+		t.Errorf("Expected no clusters after removal, got %+v", clusters)
+	}
+
+	if status := do(t, "POST", ts.URL+"/snapshot", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 without snapshot path, got %d", status)
+	}
+}
+
+func TestEmptyIndex(t *testing.T) {
+	index, err := minhash.NewMinHashFromTexts(minhash.Config{HashFunctions: 128, Bands: 32, Seed: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(NewServer(index, ""))
+	t.Cleanup(ts.Close)
+
+	var added AddResponse
+	if status := do(t, "POST", ts.URL+"/documents", Document{ID: "article", Text: article}, &added); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	if len(added.Duplicates) != 0 {
+		t.Errorf("Expected no duplicates in empty index, got %+v", added.Duplicates)
+	}
+	do(t, "POST", ts.URL+"/documents", Document{ID: "edited", Text: edited}, &added)
+	if len(added.Duplicates) != 1 || added.Duplicates[0].ID != "article" {
+		t.Errorf("Expected duplicate article, got %+v", added.Duplicates)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	index, err := minhash.NewMinHashFromTexts(minhash.Config{HashFunctions: 128, Bands: 32, Seed: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(index, "")
+	server.MaxBodyBytes = 64
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	if status := do(t, "POST", ts.URL+"/documents", Document{ID: "article", Text: article}, nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for long document, got %d", status)
+	}
+	if status := do(t, "POST", ts.URL+"/query", QueryRequest{Text: article}, nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for long query, got %d", status)
+	}
+	if status := do(t, "POST", ts.URL+"/documents", Document{ID: "short", Text: "short text"}, nil); status != http.StatusCreated {
+		t.Errorf("Expected 201 for short document, got %d", status)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.mh")
+	server, ts := newTestServer(t, path)
+
+	do(t, "POST", ts.URL+"/documents", Document{ID: "edited", Text: edited}, nil)
+	if status := do(t, "POST", ts.URL+"/snapshot", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	loaded, err := minhash.LoadFile(path, minhash.Config{HashFunctions: 128, Bands: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := loaded.QueryTextTopK(edited, 1); len(result) != 1 || result[0].ID != "edited" {
+		t.Errorf("Expected edited in snapshot, got %+v", result)
+	}
+
+	// Периодические снимки: последний делается при остановке.
+	os.Remove(path)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.SnapshotEvery(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	do(t, "DELETE", ts.URL+"/documents/edited", nil, nil)
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	loaded, err = minhash.LoadFile(path, minhash.Config{HashFunctions: 128, Bands: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := loaded.Slot("edited"); exists {
+		t.Error("Expected removal to be in the final snapshot")
+	}
+}
@@ -0,0 +1,171 @@
+package evaluation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Corpus – статьи из файла articles_N.text: идентификаторы и тексты в порядке строк.
+type Corpus struct {
+	IDs   []string
+	Texts []string
+}
+
+// ReadCorpus читает файл, где каждая строка – идентификатор статьи и её текст через пробел.
+func ReadCorpus(path string) (*Corpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	corpus := &Corpus{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		id, text, _ := strings.Cut(scanner.Text(), " ")
+		if id == "" {
+			continue
+		}
+		corpus.IDs = append(corpus.IDs, id)
+		corpus.Texts = append(corpus.Texts, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read corpus %s: %w", path, err)
+	}
+	return corpus, nil
+}
+
+// PairKey возвращает пару идентификаторов в каноническом порядке,
+// чтобы (a, b) и (b, a) считались одной парой.
+func PairKey(a, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// ReadGroundTruth читает файл articles_N.test со списком настоящих пар дубликатов.
+func ReadGroundTruth(path string) (map[[2]string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	truth := make(map[[2]string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		truth[PairKey(fields[0], fields[1])] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read ground truth %s: %w", path, err)
+	}
+	return truth, nil
+}
+
+// Metrics – качество найденных пар относительно эталона.
+type Metrics struct {
+	TruePositives int     `json:"true_positives"`
+	Reported      int     `json:"reported"`
+	Expected      int     `json:"expected"`
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+	F1            float64 `json:"f1"`
+}
+
+// Score сравнивает найденные пары с эталоном. Повторы пар учитываются один раз.
+func Score(found [][2]string, truth map[[2]string]bool) Metrics {
+	reported := make(map[[2]string]bool, len(found))
+	for _, pair := range found {
+		reported[PairKey(pair[0], pair[1])] = true
+	}
+
+	m := Metrics{Reported: len(reported), Expected: len(truth)}
+	for pair := range reported {
+		if truth[pair] {
+			m.TruePositives++
+		}
+	}
+	if m.Reported > 0 {
+		m.Precision = float64(m.TruePositives) / float64(m.Reported)
+	}
+	if m.Expected > 0 {
+		m.Recall = float64(m.TruePositives) / float64(m.Expected)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	return m
+}
+
+// Result – один прогон алгоритма на корпусе с конкретными параметрами.
+// Для SimHash HashCount – длина отпечатка в битах, Bands – число таблиц индекса,
+// MaxDistance – допустимое расстояние Хэмминга.
+type Result struct {
+	Corpus      string        `json:"corpus"`
+	Algorithm   string        `json:"algorithm"`
+	HashCount   int           `json:"hash_count"`
+	Bands       int           `json:"bands"`
+	Rows        int           `json:"rows"`
+	Threshold   float64       `json:"threshold"`
+	MaxDistance int           `json:"max_distance"`
+	Candidates  int           `json:"candidates"`
+	WallTime    time.Duration `json:"wall_time_ns"`
+	Metrics
+}
+
+var csvHeader = []string{
+	"corpus", "algorithm", "hash_count", "bands", "rows", "threshold", "max_distance", "candidates",
+	"reported", "true_positives", "expected", "precision", "recall", "f1", "wall_time_ms",
+}
+
+// WriteCSV записывает результаты в CSV с заголовком.
+func WriteCSV(w io.Writer, results []Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		record := []string{
+			r.Corpus,
+			r.Algorithm,
+			strconv.Itoa(r.HashCount),
+			strconv.Itoa(r.Bands),
+			strconv.Itoa(r.Rows),
+			strconv.FormatFloat(r.Threshold, 'f', -1, 64),
+			strconv.Itoa(r.MaxDistance),
+			strconv.Itoa(r.Candidates),
+			strconv.Itoa(r.Reported),
+			strconv.Itoa(r.TruePositives),
+			strconv.Itoa(r.Expected),
+			strconv.FormatFloat(r.Precision, 'f', 4, 64),
+			strconv.FormatFloat(r.Recall, 'f', 4, 64),
+			strconv.FormatFloat(r.F1, 'f', 4, 64),
+			strconv.FormatFloat(float64(r.WallTime)/float64(time.Millisecond), 'f', 3, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON записывает результаты массивом JSON.
+func WriteJSON(w io.Writer, results []Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
@@ -0,0 +1,94 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReadData(t *testing.T) {
+	corpus, err := ReadCorpus("../minhash/data/articles_100.text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corpus.IDs) != 100 || len(corpus.Texts) != 100 {
+		t.Fatalf("Expected 100 articles, got %d", len(corpus.IDs))
+	}
+	if corpus.IDs[0] != "t980" {
+		t.Errorf("Expected first id t980, got %s", corpus.IDs[0])
+	}
+
+	truth, err := ReadGroundTruth("../minhash/data/articles_100.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(truth) != 5 || !truth[PairKey("t5015", "t1088")] {
+		t.Errorf("Unexpected ground truth: %v", truth)
+	}
+
+	if _, err := ReadCorpus("missing.text"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestScore(t *testing.T) {
+	truth := map[[2]string]bool{
+		PairKey("a", "b"): true,
+		PairKey("c", "d"): true,
+	}
+	found := [][2]string{{"b", "a"}, {"a", "b"}, {"a", "c"}}
+
+	m := Score(found, truth)
+	if m.Reported != 2 || m.TruePositives != 1 || m.Expected != 2 {
+		t.Fatalf("Unexpected counts: %+v", m)
+	}
+	if m.Precision != 0.5 || m.Recall != 0.5 || math.Abs(m.F1-0.5) > 1e-12 {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+
+	if m := Score(nil, truth); m.Precision != 0 || m.Recall != 0 || m.F1 != 0 {
+		t.Errorf("Expected zero metrics for empty result, got %+v", m)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results := []Result{{
+		Corpus:    "articles_100",
+		Algorithm: "minhash",
+		HashCount: 100,
+		Bands:     20,
+		Rows:      5,
+		WallTime:  1500 * time.Microsecond,
+		Metrics:   Metrics{Reported: 5, TruePositives: 5, Expected: 5, Precision: 1, Recall: 1, F1: 1},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[1]) != len(csvHeader) {
+		t.Fatalf("Unexpected CSV: %v", records)
+	}
+	if records[1][len(csvHeader)-1] != "1.500" {
+		t.Errorf("Expected wall time 1.500 ms, got %s", records[1][len(csvHeader)-1])
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0] != results[0] {
+		t.Errorf("Expected %+v, got %+v", results, decoded)
+	}
+}
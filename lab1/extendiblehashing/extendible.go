@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 const (
@@ -22,15 +23,27 @@ type ExtendableHashTable struct {
 	Buckets      map[int]*Bucket
 	GlobalDepth  int
 	nextBucketId int
+	// Каталог, в котором хранятся файлы бакетов (с завершающим "/").
+	storagePath string
 }
 
 // NewExtendableHashTable создаёт новую расширяемую хэш‑таблицу и инициализирует 2^GlobalDepth бакетов.
 func NewExtendableHashTable() *ExtendableHashTable {
-	os.MkdirAll(STORAGE_PATH, os.ModePerm)
+	return NewExtendableHashTableAt(STORAGE_PATH)
+}
+
+// NewExtendableHashTableAt создаёт расширяемую хэш‑таблицу, хранящую бакеты в каталоге path.
+// Нужна, чтобы несколько независимых таблиц не перезаписывали файлы друг друга.
+func NewExtendableHashTableAt(path string) *ExtendableHashTable {
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	os.MkdirAll(path, os.ModePerm)
 	eht := &ExtendableHashTable{
 		Buckets:      make(map[int]*Bucket),
 		GlobalDepth:  1,
 		nextBucketId: 0,
+		storagePath:  path,
 	}
 	// Инициализируем 2^GlobalDepth бакетов
 	for i := 0; i < (1 << eht.GlobalDepth); i++ {
@@ -87,6 +100,41 @@ func (eht *ExtendableHashTable) Get(key string) (interface{}, bool) {
 	return value, exists
 }
 
+// Delete удаляет ключ из таблицы и возвращает true, если ключ был найден.
+// Бакеты при удалении не сливаются.
+func (eht *ExtendableHashTable) Delete(key string) bool {
+	dirIndex := eht.getBKey(key)
+	bucket := eht.loadBucketFromFile(dirIndex)
+	if _, exists := bucket.Items[key]; !exists {
+		return false
+	}
+	delete(bucket.Items, key)
+	eht.saveBucketToFile(bucket)
+	return true
+}
+
+// Items возвращает все пары ключ-значение таблицы.
+// Каждый бакет читается один раз, даже если на него ссылаются несколько индексов директории.
+func (eht *ExtendableHashTable) Items() map[string]interface{} {
+	items := make(map[string]interface{})
+	seen := make(map[int]bool)
+	for dirIndex, bucket := range eht.Buckets {
+		if seen[bucket.Id] {
+			continue
+		}
+		seen[bucket.Id] = true
+		for key, value := range eht.loadBucketFromFile(dirIndex).Items {
+			items[key] = value
+		}
+	}
+	return items
+}
+
+// Len возвращает количество ключей в таблице.
+func (eht *ExtendableHashTable) Len() int {
+	return len(eht.Items())
+}
+
 // expandDirectory расширяет директорию, удваивая число указателей, копируя старые бакеты.
 func (eht *ExtendableHashTable) expandDirectory() {
 	oldBuckets := make(map[int]*Bucket)
@@ -158,7 +206,7 @@ func (eht *ExtendableHashTable) splitBucket(dirIndex int) {
 
 // saveBucketToFile сохраняет бакет b в файл с именем, основанным на его уникальном Id.
 func (eht *ExtendableHashTable) saveBucketToFile(b *Bucket) {
-	filePath := fmt.Sprintf("%s%d.json", STORAGE_PATH, b.Id)
+	filePath := fmt.Sprintf("%s%d.json", eht.storagePath, b.Id)
 	data, err := json.MarshalIndent(b, "", "  ")
 	if err != nil {
 		fmt.Println("Ошибка при маршалинге бакета:", err)
@@ -174,7 +222,7 @@ func (eht *ExtendableHashTable) saveBucketToFile(b *Bucket) {
 // используя для имени файла уникальный Id бакета.
 func (eht *ExtendableHashTable) loadBucketFromFile(dirIndex int) *Bucket {
 	bucket := eht.Buckets[dirIndex]
-	filePath := fmt.Sprintf("%s%d.json", STORAGE_PATH, bucket.Id)
+	filePath := fmt.Sprintf("%s%d.json", eht.storagePath, bucket.Id)
 	data, err := os.ReadFile(filePath)
 	if err == nil {
 		var b Bucket
@@ -0,0 +1,95 @@
+package hyperloglog
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	const p = 14
+	bound := 3 * 1.04 / math.Sqrt(1<<p)
+
+	sketch, _ := New(p)
+	added := 0
+	for _, n := range []int{10, 100, 1000, 10000, 100000, 1000000} {
+		for ; added < n; added++ {
+			sketch.AddString(fmt.Sprintf("element-%d", added))
+		}
+		// Повторные элементы не меняют оценку.
+		sketch.AddString("element-0")
+
+		estimate := sketch.Estimate()
+		relErr := math.Abs(estimate-float64(n)) / float64(n)
+		t.Logf("n=%d sparse=%v estimate=%.1f error=%.4f", n, sketch.Sparse(), estimate, relErr)
+		if sketch.Sparse() && relErr > 0.01 {
+			t.Errorf("n=%d: sparse estimate %.1f, expected error below 1%%", n, estimate)
+		}
+		if relErr > bound {
+			t.Errorf("n=%d: estimate %.1f, error %.4f above %.4f", n, estimate, relErr, bound)
+		}
+	}
+	if sketch.Sparse() {
+		t.Error("Expected sketch to switch to dense registers")
+	}
+
+	empty, _ := New(p)
+	if e := empty.Estimate(); e != 0 {
+		t.Errorf("Expected zero for empty sketch, got %v", e)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	for _, sizes := range [][2]int{{50, 80}, {50, 50000}, {40000, 60000}} {
+		a, _ := New(12)
+		b, _ := New(12)
+		all, _ := New(12)
+		for i := 0; i < sizes[0]; i++ {
+			a.AddString(fmt.Sprint(i))
+			all.AddString(fmt.Sprint(i))
+		}
+		// Множества пересекаются наполовину меньшего.
+		for i := sizes[0] / 2; i < sizes[0]/2+sizes[1]; i++ {
+			b.AddString(fmt.Sprint(i))
+			all.AddString(fmt.Sprint(i))
+		}
+
+		merged := a.Clone()
+		if err := merged.Merge(b); err != nil {
+			t.Fatal(err)
+		}
+		reversed := b.Clone()
+		reversed.Merge(a)
+
+		// Объединение скетчей совпадает со скетчем объединения, если оба плотные.
+		if !merged.Sparse() && !all.Sparse() {
+			for i := range merged.registers {
+				if merged.registers[i] != all.registers[i] {
+					t.Fatalf("sizes %v: register %d differs after merge", sizes, i)
+				}
+			}
+		}
+		if math.Abs(merged.Estimate()-all.Estimate()) > 0.001*all.Estimate() ||
+			math.Abs(reversed.Estimate()-all.Estimate()) > 0.001*all.Estimate() {
+			t.Errorf("sizes %v: merged %.1f, reversed %.1f, direct %.1f",
+				sizes, merged.Estimate(), reversed.Estimate(), all.Estimate())
+		}
+		if a.Estimate() > float64(sizes[0])*1.1 {
+			t.Errorf("Merge modified the source sketch")
+		}
+	}
+
+	a, _ := New(10)
+	b, _ := New(11)
+	if err := a.Merge(b); err != ErrPrecisionMismatch {
+		t.Errorf("Expected ErrPrecisionMismatch, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, p := range []int{3, 19} {
+		if _, err := New(p); err != ErrInvalidPrecision {
+			t.Errorf("p=%d: expected ErrInvalidPrecision, got %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Формат: версия, точность p, режим (0 – разреженный, 1 – плотный).
+// Разреженный режим: число регистров, затем для каждого по возрастанию номера –
+// разность с предыдущим номером (varint) и ранг. Плотный режим: 2^p байт регистров.
+const (
+	marshalVersion = 1
+	modeSparse     = 0
+	modeDense      = 1
+)
+
+// ErrCorrupted – данные не являются сериализованным скетчем.
+var ErrCorrupted = errors.New("corrupted hyperloglog sketch")
+
+// MarshalBinary реализует encoding.BinaryMarshaler.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	if s.registers != nil {
+		data := make([]byte, 3, 3+len(s.registers))
+		data[0], data[1], data[2] = marshalVersion, s.p, modeDense
+		return append(data, s.registers...), nil
+	}
+
+	data := []byte{marshalVersion, s.p, modeSparse}
+	data = binary.AppendUvarint(data, uint64(len(s.sparse)))
+	prev := uint32(0)
+	for _, index := range s.sortedSparse() {
+		data = binary.AppendUvarint(data, uint64(index-prev))
+		data = append(data, s.sparse[index])
+		prev = index
+	}
+	return data, nil
+}
+
+// UnmarshalBinary реализует encoding.BinaryUnmarshaler.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 || data[0] != marshalVersion {
+		return ErrCorrupted
+	}
+	p, mode, data := data[1], data[2], data[3:]
+	if p < MinPrecision || p > MaxPrecision {
+		return fmt.Errorf("%w: precision %d", ErrCorrupted, p)
+	}
+
+	switch mode {
+	case modeDense:
+		if len(data) != 1<<p {
+			return fmt.Errorf("%w: expected %d registers, got %d", ErrCorrupted, 1<<p, len(data))
+		}
+		for _, rank := range data {
+			if int(rank) > 64-int(p)+1 {
+				return fmt.Errorf("%w: rank %d", ErrCorrupted, rank)
+			}
+		}
+		*s = Sketch{p: p, registers: append([]uint8(nil), data...)}
+	case modeSparse:
+		count, n := binary.Uvarint(data)
+		if n <= 0 || count > 1<<sparsePrecision {
+			return ErrCorrupted
+		}
+		data = data[n:]
+		// Каждый регистр занимает не меньше 2 байт. Число из заголовка не используется
+		// для выделения памяти, пока не проверено по длине данных.
+		if count > uint64(len(data))/2 {
+			return fmt.Errorf("%w: %d sparse registers in %d bytes", ErrCorrupted, count, len(data))
+		}
+		sparse := make(map[uint32]uint8)
+		index := uint64(0)
+		for i := uint64(0); i < count; i++ {
+			delta, n := binary.Uvarint(data)
+			if n <= 0 || len(data) <= n {
+				return ErrCorrupted
+			}
+			index += delta
+			rank := data[n]
+			if index >= 1<<sparsePrecision || (i > 0 && delta == 0) || rank == 0 || int(rank) > 64-sparsePrecision+1 {
+				return fmt.Errorf("%w: invalid sparse register", ErrCorrupted)
+			}
+			sparse[uint32(index)] = rank
+			data = data[n+1:]
+		}
+		if len(data) != 0 {
+			return fmt.Errorf("%w: %d trailing bytes", ErrCorrupted, len(data))
+		}
+		*s = Sketch{p: p, sparse: sparse}
+	default:
+		return fmt.Errorf("%w: unknown mode %d", ErrCorrupted, mode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package hyperloglog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, n := range []int{0, 100, 100000} {
+		sketch, _ := New(12)
+		for i := 0; i < n; i++ {
+			sketch.AddString(fmt.Sprint(i))
+		}
+
+		data, err := sketch.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Sketch
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		if decoded.Sparse() != sketch.Sparse() || decoded.Estimate() != sketch.Estimate() {
+			t.Errorf("n=%d: expected estimate %v, got %v", n, sketch.Estimate(), decoded.Estimate())
+		}
+		if !sketch.Sparse() && !reflect.DeepEqual(decoded.registers, sketch.registers) {
+			t.Errorf("n=%d: registers differ", n)
+		}
+
+		// Декодированный скетч продолжает принимать элементы.
+		decoded.AddString("new element")
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"version":   {9, 12, modeSparse, 0},
+		"precision": {marshalVersion, 30, modeDense},
+		"dense":     {marshalVersion, 4, modeDense, 1, 2},
+		"trailing":  {marshalVersion, 12, modeSparse, 0, 1},
+		"count":     {marshalVersion, 12, modeSparse, 0x80, 0x80, 0x80, 0x08, 1, 5},
+		"mode":      {marshalVersion, 12, 7},
+	} {
+		var s Sketch
+		if err := s.UnmarshalBinary(data); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s: expected ErrCorrupted, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Package murmur содержит финализатор MurmurHash3 для доперемешивания 64-битных хэшей.
+package murmur
+
+// Mix – финализатор MurmurHash3. FNV-1a плохо перемешивает старшие биты коротких строк,
+// поэтому хэш нужно пропустить через Mix, если используются все его биты.
+func Mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,23 @@
+package murmur
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// TestMixAvalanche проверяет, что смена одного бита входа меняет около половины битов выхода
+func TestMixAvalanche(t *testing.T) {
+	if Mix(0) != 0 {
+		t.Errorf("Expected Mix(0) = 0, got %#x", Mix(0))
+	}
+	total, trials := 0, 0
+	for x := uint64(1); x < 1000; x++ {
+		for bit := 0; bit < 64; bit++ {
+			total += bits.OnesCount64(Mix(x) ^ Mix(x^1<<bit))
+			trials++
+		}
+	}
+	if mean := float64(total) / float64(trials); mean < 30 || mean > 34 {
+		t.Errorf("Expected about 32 flipped bits on average, got %.2f", mean)
+	}
+}
@@ -0,0 +1,192 @@
+package minhash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// validBBits проверяет число хранимых бит: 0 (полные значения), 1, 2, 4 или 8.
+func validBBits(b int) bool {
+	return b == 0 || b == 1 || b == 2 || b == 4 || b == 8
+}
+
+// compact переводит полную сигнатуру в хранимую форму: в режиме b-bit остаются только
+// младшие BBits бит каждого минимума, упакованные по 64/BBits значений в слово.
+func (mh *MinHash) compact(sig []uint64) []uint64 {
+	if mh.BBits == 0 {
+		return sig
+	}
+	return packBits(sig, mh.BBits)
+}
+
+// packBits упаковывает младшие b бит каждого значения.
+func packBits(values []uint64, b int) []uint64 {
+	perWord := 64 / b
+	mask := uint64(1)<<b - 1
+	packed := make([]uint64, (len(values)+perWord-1)/perWord)
+	for i, v := range values {
+		packed[i/perWord] |= (v & mask) << (uint(i%perWord) * uint(b))
+	}
+	return packed
+}
+
+// unpackRange возвращает значения с позиций [start, end) упакованной сигнатуры.
+func unpackRange(packed []uint64, b, start, end int) []uint64 {
+	perWord := 64 / b
+	mask := uint64(1)<<b - 1
+	values := make([]uint64, 0, end-start)
+	for i := start; i < end; i++ {
+		values = append(values, packed[i/perWord]>>(uint(i%perWord)*uint(b))&mask)
+	}
+	return values
+}
+
+// bbitMatches возвращает долю совпавших b-битных значений среди первых size позиций.
+func bbitMatches(a, b []uint64, bits, size int) float64 {
+	perWord := 64 / bits
+	mask := uint64(1)<<bits - 1
+	matches := 0
+	for i := 0; i < size; i++ {
+		shift := uint(i%perWord) * uint(bits)
+		if (a[i/perWord]>>shift)&mask == (b[i/perWord]>>shift)&mask {
+			matches++
+		}
+	}
+	return float64(matches) / float64(size)
+}
+
+// bbitA – величина A_{j,b} из оценки Ли–Кёнига для доли r = |S|/D:
+// r(1-r)^(2^b-1) / (1-(1-r)^(2^b)). При r → 0 стремится к 1/2^b.
+func bbitA(r float64, b int) float64 {
+	n := math.Ldexp(1, b)
+	if r <= 0 {
+		return 1 / n
+	}
+	logRest := math.Log1p(-r)
+	return r * math.Exp((n-1)*logRest) / -math.Expm1(n*logRest)
+}
+
+// bbitEstimate – оценка сходства Жаккара по доле совпавших b-битных значений
+// (Li, König, "b-Bit Minwise Hashing", 2010):
+// R = (P - C1) / (1 - C2), где C1, C2 учитывают случайные совпадения младших бит
+// для множеств размеров sizeA и sizeB во вселенной хэшей из 2^61 - 1 значений.
+func bbitEstimate(matches float64, b, sizeA, sizeB int) float64 {
+	r1 := float64(sizeA) / mersennePrime
+	r2 := float64(sizeB) / mersennePrime
+	a1, a2 := bbitA(r1, b), bbitA(r2, b)
+
+	var c1, c2 float64
+	if r1+r2 == 0 {
+		c1, c2 = a1, a1
+	} else {
+		c1 = a1*r2/(r1+r2) + a2*r1/(r1+r2)
+		c2 = a1*r1/(r1+r2) + a2*r2/(r1+r2)
+	}
+
+	estimate := (matches - c1) / (1 - c2)
+	return math.Min(math.Max(estimate, 0), 1)
+}
+
+// SignatureBytes возвращает объём памяти, занятый хранимыми сигнатурами.
+func (mh *MinHash) SignatureBytes() int {
+	total := 0
+	for _, sig := range mh.Signatures {
+		total += len(sig) * 8
+	}
+	return total
+}
+
+// BBitReport – сравнение режима b-bit с полными 64-битными сигнатурами.
+type BBitReport struct {
+	Bits int
+	// Bytes и FullBytes – память под сигнатуры в режиме b-bit и с полными значениями.
+	Bytes     int
+	FullBytes int
+	// MeanAbsError и MaxAbsError – ошибка оценки сходства относительно точного коэффициента Жаккара.
+	MeanAbsError float64
+	MaxAbsError  float64
+	// FullMeanAbsError – та же ошибка для полных сигнатур.
+	FullMeanAbsError float64
+}
+
+// CompareBBits строит индексы с полными и b-битными сигнатурами для каждого значения из bits
+// и сравнивает занятую память и точность оценки сходства на pairs > 0 случайных парах документов.
+// Все индексы строятся с параметрами cfg, BBits из cfg не используется.
+func CompareBBits(cfg Config, sets [][]string, bits []int, pairs int) ([]BBitReport, error) {
+	if pairs <= 0 {
+		return nil, fmt.Errorf("number of sampled pairs must be positive, got %d", pairs)
+	}
+	if len(sets) < 2 {
+		return nil, errors.New("need at least two documents to sample pairs")
+	}
+	cfg.BBits = 0
+	full, err := NewMinHash(cfg, sets)
+	if err != nil {
+		return nil, err
+	}
+	// Случайное зерно выбирается один раз, чтобы все индексы имели одинаковые перестановки.
+	cfg.Seed = full.Seed
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	sampled := make([][2]int, 0, pairs)
+	exact := make([]float64, 0, pairs)
+	fullError := 0.0
+	for len(sampled) < pairs {
+		i, j := rng.Intn(len(sets)), rng.Intn(len(sets))
+		if i == j {
+			continue
+		}
+		sampled = append(sampled, [2]int{i, j})
+		exact = append(exact, exactJaccard(sets[i], sets[j]))
+		fullError += math.Abs(full.estimatePair(i, j) - exact[len(exact)-1])
+	}
+
+	var reports []BBitReport
+	for _, b := range bits {
+		cfg.BBits = b
+		mh, err := NewMinHash(cfg, sets)
+		if err != nil {
+			return nil, err
+		}
+		report := BBitReport{
+			Bits:             b,
+			Bytes:            mh.SignatureBytes(),
+			FullBytes:        full.SignatureBytes(),
+			FullMeanAbsError: fullError / float64(pairs),
+		}
+		for k, pair := range sampled {
+			err := math.Abs(mh.estimatePair(pair[0], pair[1]) - exact[k])
+			report.MeanAbsError += err
+			report.MaxAbsError = math.Max(report.MaxAbsError, err)
+		}
+		report.MeanAbsError /= float64(pairs)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// exactJaccard вычисляет точный коэффициент Жаккара двух множеств.
+func exactJaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, elem := range a {
+		setA[elem] = true
+	}
+	setB := make(map[string]bool, len(b))
+	intersection := 0
+	for _, elem := range b {
+		if setB[elem] {
+			continue
+		}
+		setB[elem] = true
+		if setA[elem] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,110 @@
+package minhash
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestPackBits проверяет упаковку и распаковку младших бит
+func TestPackBits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]uint64, 37)
+	for i := range values {
+		values[i] = rng.Uint64()
+	}
+
+	for _, b := range []int{1, 2, 4, 8} {
+		packed := packBits(values, b)
+		if expected := (len(values)*b + 63) / 64; len(packed) != expected {
+			t.Fatalf("b=%d: expected %d words, got %d", b, expected, len(packed))
+		}
+		unpacked := unpackRange(packed, b, 0, len(values))
+		for i, v := range values {
+			if unpacked[i] != v&(1<<b-1) {
+				t.Fatalf("b=%d: position %d: expected %d, got %d", b, i, v&(1<<b-1), unpacked[i])
+			}
+		}
+		if got := bbitMatches(packed, packed, b, len(values)); got != 1 {
+			t.Errorf("b=%d: expected full match, got %v", b, got)
+		}
+	}
+}
+
+// TestBBitEstimate проверяет поправку на случайные совпадения
+func TestBBitEstimate(t *testing.T) {
+	// Для малых множеств поправка сводится к (P - 2^-b) / (1 - 2^-b).
+	for _, b := range []int{1, 2, 4, 8} {
+		c := math.Ldexp(1, -b)
+		for _, p := range []float64{c, 0.6, 1} {
+			expected := (p - c) / (1 - c)
+			if got := bbitEstimate(p, b, 100, 300); math.Abs(got-expected) > 1e-9 {
+				t.Errorf("b=%d P=%v: expected %v, got %v", b, p, expected, got)
+			}
+		}
+	}
+	if got := bbitEstimate(0, 1, 10, 10); got != 0 {
+		t.Errorf("Expected estimate clamped to 0, got %v", got)
+	}
+}
+
+// TestBBitMinHash проверяет поиск дубликатов на упакованных сигнатурах
+func TestBBitMinHash(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_100.text")
+	expected := create_expected_result_from_file("data/articles_100.test")
+
+	for _, b := range []int{2, 4, 8} {
+		mh := mustMinHash(t, Config{HashFunctions: 128, Bands: 16, Seed: 7, BBits: b}, sets)
+		if got, max := mh.SignatureBytes(), len(sets)*128*b/8; got != max {
+			t.Errorf("b=%d: expected %d bytes, got %d", b, max, got)
+		}
+
+		found := 0
+		for _, rec := range mh.Similarity() {
+			pair := [2]string{id_to_num[int(rec[0])], id_to_num[int(rec[1])]}
+			if expected[pair] != 0 && rec[2] > 0.85 {
+				found++
+			}
+		}
+		if found != len(expected) {
+			t.Errorf("b=%d: expected %d duplicate pairs, found %d", b, len(expected), found)
+		}
+
+		if result := mh.QueryTopK(sets[3], 1); len(result) != 1 || result[0].ID != "3" || result[0].Similarity != 1 {
+			t.Errorf("b=%d: expected document itself, got %v", b, result)
+		}
+	}
+}
+
+// TestCompareBBits выводит отчёт об экономии памяти и потере точности
+func TestCompareBBits(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+
+	reports, err := CompareBBits(Config{HashFunctions: 256, Bands: 32, Seed: 1}, sets, []int{1, 2, 4, 8}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reports {
+		t.Logf("b=%d: %d bytes instead of %d (%.1fx smaller), mean abs error %.4f (full %.4f), max %.4f",
+			r.Bits, r.Bytes, r.FullBytes, float64(r.FullBytes)/float64(r.Bytes), r.MeanAbsError, r.FullMeanAbsError, r.MaxAbsError)
+		if r.Bytes*64 != r.FullBytes*r.Bits {
+			t.Errorf("b=%d: unexpected memory %d of %d", r.Bits, r.Bytes, r.FullBytes)
+		}
+	}
+	if last := reports[len(reports)-1]; last.MeanAbsError > last.FullMeanAbsError+0.01 {
+		t.Errorf("b=8: error %.4f is much larger than full %.4f", last.MeanAbsError, last.FullMeanAbsError)
+	}
+}
+
+func TestCompareBBitsInvalid(t *testing.T) {
+	cfg := Config{HashFunctions: 16, Bands: 4, Seed: 1}
+	sets := [][]string{{"a", "b"}, {"b", "c"}}
+	for _, pairs := range []int{0, -1} {
+		if _, err := CompareBBits(cfg, sets, []int{1}, pairs); err == nil {
+			t.Errorf("CompareBBits with %d pairs returned no error", pairs)
+		}
+	}
+	if _, err := CompareBBits(cfg, sets[:1], []int{1}, 10); err == nil {
+		t.Error("CompareBBits with one document returned no error")
+	}
+}
@@ -0,0 +1,74 @@
+package minhash
+
+import (
+	"strings"
+
+	"lab1/hyperloglog"
+	"lab1/internal/murmur"
+)
+
+// setSketch строит скетч HyperLogLog множества или возвращает nil, если скетчи выключены.
+func (mh *MinHash) setSketch(set []string) *hyperloglog.Sketch {
+	if mh.SketchPrecision == 0 {
+		return nil
+	}
+	sketch, _ := hyperloglog.New(mh.SketchPrecision)
+	for _, elem := range set {
+		sketch.Add(murmur.Mix(hash(elem)))
+	}
+	return sketch
+}
+
+// textSketch строит скетч текста по тем же элементам, что и textSignature.
+func (mh *MinHash) textSketch(text string) *hyperloglog.Sketch {
+	if mh.SketchPrecision == 0 {
+		return nil
+	}
+	if mh.Tokenizer == nil {
+		return mh.setSketch(strings.Fields(text))
+	}
+	sketch, _ := hyperloglog.New(mh.SketchPrecision)
+	for _, h := range mh.Tokenizer.HashedShingles(text) {
+		sketch.Add(murmur.Mix(h))
+	}
+	return sketch
+}
+
+// EstimateUnion оценивает мощность объединения документов a и b. Если индекс хранит
+// скетчи HyperLogLog, они объединяются; иначе используются размеры документов
+// и оценка сходства: |A ∪ B| = (|A| + |B|) / (1 + J).
+func (mh *MinHash) EstimateUnion(a, b string) (float64, error) {
+	i, j, err := mh.slotPair(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return mh.estimateUnion(i, j), nil
+}
+
+// EstimateIntersection оценивает мощность пересечения документов a и b
+// как произведение оценки сходства Жаккара на оценку мощности объединения.
+func (mh *MinHash) EstimateIntersection(a, b string) (float64, error) {
+	i, j, err := mh.slotPair(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return mh.estimatePair(i, j) * mh.estimateUnion(i, j), nil
+}
+
+func (mh *MinHash) slotPair(a, b string) (int, int, error) {
+	i, okA := mh.slots[a]
+	j, okB := mh.slots[b]
+	if !okA || !okB {
+		return 0, 0, ErrDocumentNotFound
+	}
+	return i, j, nil
+}
+
+func (mh *MinHash) estimateUnion(i, j int) float64 {
+	if mh.Sketches != nil && mh.Sketches[i] != nil && mh.Sketches[j] != nil {
+		union := mh.Sketches[i].Clone()
+		union.Merge(mh.Sketches[j])
+		return union.Estimate()
+	}
+	return float64(mh.Sizes[i]+mh.Sizes[j]) / (1 + mh.estimatePair(i, j))
+}
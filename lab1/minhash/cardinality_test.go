@@ -0,0 +1,90 @@
+package minhash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestEstimateIntersection сравнивает оценки пересечения и объединения с точными значениями
+func TestEstimateIntersection(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_1000.text")
+
+	configs := []struct {
+		name string
+		cfg  Config
+	}{
+		{"sizes", Config{HashFunctions: 256, Bands: 32, Seed: 1}},
+		{"sketches", Config{HashFunctions: 256, Bands: 32, Seed: 1, SketchPrecision: 12}},
+	}
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			mh := mustMinHash(t, config.cfg, sets)
+
+			var unionErr, intersectionErr float64
+			pairs := 0
+			for _, pair := range mh.FindSimilarPairs() {
+				a, b := fmt.Sprint(pair[0]), fmt.Sprint(pair[1])
+				union, err := mh.EstimateUnion(a, b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				intersection, err := mh.EstimateIntersection(a, b)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				exactUnion, exactIntersection := unionIntersection(sets[pair[0]], sets[pair[1]])
+				unionErr += math.Abs(union-exactUnion) / exactUnion
+				intersectionErr += math.Abs(intersection-exactIntersection) / exactIntersection
+				pairs++
+			}
+			unionErr /= float64(pairs)
+			intersectionErr /= float64(pairs)
+			t.Logf("%d pairs: mean relative error union %.4f, intersection %.4f", pairs, unionErr, intersectionErr)
+			if unionErr > 0.05 || intersectionErr > 0.1 {
+				t.Errorf("Mean relative error too large: union %.4f, intersection %.4f", unionErr, intersectionErr)
+			}
+
+			if _, err := mh.EstimateIntersection("0", "missing"); err != ErrDocumentNotFound {
+				t.Errorf("Expected ErrDocumentNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+// TestSketchesAddRemove проверяет, что скетчи следуют за добавлением и удалением документов
+func TestSketchesAddRemove(t *testing.T) {
+	sets := [][]string{{"a", "b", "c"}, {"b", "c", "d"}}
+	mh := mustMinHash(t, Config{HashFunctions: 64, Bands: 16, SketchPrecision: 10}, sets)
+
+	if err := mh.Add("copy", sets[0]); err != nil {
+		t.Fatal(err)
+	}
+	if union, _ := mh.EstimateUnion("0", "copy"); math.Abs(union-3) > 0.1 {
+		t.Errorf("Expected union 3 for identical documents, got %v", union)
+	}
+	if err := mh.Remove("0"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mh.Sketches) != len(mh.Signatures) || mh.Sketches[0] != nil {
+		t.Error("Expected sketch of removed document to be released")
+	}
+}
+
+func unionIntersection(a, b []string) (union, intersection float64) {
+	setA := make(map[string]bool)
+	for _, elem := range a {
+		setA[elem] = true
+	}
+	setB := make(map[string]bool)
+	for _, elem := range b {
+		setB[elem] = true
+	}
+	for elem := range setB {
+		if setA[elem] {
+			intersection++
+		}
+	}
+	return float64(len(setA)+len(setB)) - intersection, intersection
+}
@@ -0,0 +1,112 @@
+package minhash
+
+import "sort"
+
+// Cluster – группа документов, связанных попарным сходством не ниже порога.
+type Cluster struct {
+	// Representative – документ, представляющий группу (добавленный раньше остальных).
+	Representative string `json:"representative"`
+	// Members – идентификаторы документов группы в порядке добавления, включая представителя.
+	Members []string `json:"members"`
+}
+
+// Clusters объединяет кандидатов из бакетов, оценка сходства которых не ниже threshold,
+// и возвращает компоненты связности из двух и более документов.
+// Пары внутри уже объединённой компоненты повторно не сравниваются.
+func (mh *MinHash) Clusters(threshold float64) []Cluster {
+	return mh.clusters(threshold, mh.estimatePair)
+}
+
+// ClustersExact работает как Clusters, но после оценки по сигнатурам проверяет
+// точный коэффициент Жаккара по исходным множествам sets (по идентификаторам документов).
+func (mh *MinHash) ClustersExact(threshold float64, sets map[string][]string) []Cluster {
+	return mh.clusters(threshold, func(i, j int) float64 {
+		if mh.estimatePair(i, j) < threshold {
+			return 0
+		}
+		return exactJaccard(sets[mh.IDs[i]], sets[mh.IDs[j]])
+	})
+}
+
+func (mh *MinHash) clusters(threshold float64, similarity func(i, j int) float64) []Cluster {
+	uf := newUnionFind(len(mh.Signatures))
+
+	for _, bandBuckets := range mh.Buckets {
+		for _, candidates := range bandBuckets {
+			for i := 0; i < len(candidates); i++ {
+				for j := i + 1; j < len(candidates); j++ {
+					a, b := candidates[i], candidates[j]
+					if uf.find(a) == uf.find(b) {
+						continue
+					}
+					if similarity(a, b) >= threshold {
+						uf.union(a, b)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for slot, sig := range mh.Signatures {
+		if sig == nil {
+			continue
+		}
+		root := uf.find(slot)
+		groups[root] = append(groups[root], slot)
+	}
+
+	var roots []int
+	for root, slots := range groups {
+		if len(slots) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	// Позиции перебираются по возрастанию, поэтому первая позиция группы – самая ранняя.
+	sort.Slice(roots, func(i, j int) bool { return groups[roots[i]][0] < groups[roots[j]][0] })
+
+	clusters := make([]Cluster, len(roots))
+	for i, root := range roots {
+		members := make([]string, len(groups[root]))
+		for k, slot := range groups[root] {
+			members[k] = mh.IDs[slot]
+		}
+		clusters[i] = Cluster{Representative: members[0], Members: members}
+	}
+	return clusters
+}
+
+// unionFind – система непересекающихся множеств со сжатием путей и объединением по размеру.
+type unionFind struct {
+	parent []int
+	size   []int
+}
+
+func newUnionFind(n int) *unionFind {
+	uf := &unionFind{parent: make([]int, n), size: make([]int, n)}
+	for i := range uf.parent {
+		uf.parent[i] = i
+		uf.size[i] = 1
+	}
+	return uf
+}
+
+func (uf *unionFind) find(x int) int {
+	for uf.parent[x] != x {
+		uf.parent[x] = uf.parent[uf.parent[x]]
+		x = uf.parent[x]
+	}
+	return x
+}
+
+func (uf *unionFind) union(a, b int) {
+	a, b = uf.find(a), uf.find(b)
+	if a == b {
+		return
+	}
+	if uf.size[a] < uf.size[b] {
+		a, b = b, a
+	}
+	uf.parent[b] = a
+	uf.size[a] += uf.size[b]
+}
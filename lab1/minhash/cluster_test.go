@@ -0,0 +1,93 @@
+package minhash
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestClusters проверяет, что дубликаты из эталона попадают в одну группу
+func TestClusters(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_1000.text")
+	expected := create_expected_result_from_file("data/articles_1000.test")
+
+	ids := make([]string, len(sets))
+	for num, id := range id_to_num {
+		ids[num] = id
+	}
+
+	mh := mustMinHash(t, Config{HashFunctions: 100, Bands: 20, Seed: 1, IDs: ids}, sets)
+	clusters := mh.Clusters(0.8)
+
+	cluster_of := make(map[string]int)
+	for c, cluster := range clusters {
+		if cluster.Representative != cluster.Members[0] {
+			t.Errorf("Cluster %v: representative is not the first member", cluster)
+		}
+		for _, id := range cluster.Members {
+			if _, exists := cluster_of[id]; exists {
+				t.Fatalf("Document %s belongs to several clusters", id)
+			}
+			cluster_of[id] = c
+		}
+	}
+
+	for pair := range expected {
+		a, okA := cluster_of[pair[0]]
+		b, okB := cluster_of[pair[1]]
+		if !okA || !okB || a != b {
+			t.Errorf("Expected %s and %s in the same cluster", pair[0], pair[1])
+		}
+	}
+	t.Logf("%d clusters for %d expected pairs", len(clusters), len(expected))
+}
+
+// TestClustersTransitive проверяет объединение цепочки похожих документов и точную проверку
+func TestClustersTransitive(t *testing.T) {
+	base := make([]string, 100)
+	for i := range base {
+		base[i] = string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	shifted := func(from int) []string {
+		return append(append([]string{}, base[from:]...), "x"+base[0], "y"+base[0])[:100]
+	}
+
+	sets := [][]string{
+		base,
+		{"unrelated", "words", "only"},
+		shifted(2),
+		shifted(4),
+		{"other", "unrelated", "set"},
+	}
+	ids := []string{"base", "u1", "shift2", "shift4", "u2"}
+	mh := mustMinHash(t, Config{HashFunctions: 128, Bands: 32, Seed: 3, IDs: ids}, sets)
+
+	exact := make(map[string][]string)
+	for i, id := range ids {
+		exact[id] = sets[i]
+	}
+
+	expected := []Cluster{{Representative: "base", Members: []string{"base", "shift2", "shift4"}}}
+	if got := mh.Clusters(0.9); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Clusters: expected %v, got %v", expected, got)
+	}
+	if got := mh.ClustersExact(0.9, exact); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ClustersExact: expected %v, got %v", expected, got)
+	}
+
+	// Точная проверка отбрасывает пары ниже порога, даже если оценка по сигнатурам прошла бы.
+	if got := mh.ClustersExact(0.99, exact); len(got) != 0 {
+		t.Errorf("ClustersExact(0.99): expected no clusters, got %v", got)
+	}
+
+	// Удалённые документы не попадают в группы.
+	if err := mh.Remove("shift2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range mh.Clusters(0.9) {
+		for _, id := range cluster.Members {
+			if id == "shift2" {
+				t.Errorf("Removed document in cluster %v", cluster)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package minhash
+
+import (
+	"errors"
+	"fmt"
+
+	"lab1/hyperloglog"
+	"lab1/tokenizer"
+)
+
+// ErrInvalidConfig – параметры индекса недопустимы; подробности в тексте обёрнутой ошибки.
+var ErrInvalidConfig = errors.New("invalid MinHash config")
+
+// Config задаёт параметры индекса MinHash.
+type Config struct {
+	// HashFunctions – число хэш-функций, то есть длина сигнатуры.
+	HashFunctions int
+	// Bands – число полос LSH. HashFunctions должно делиться на Bands,
+	// чтобы каждая строка сигнатуры попала ровно в одну полосу.
+	Bands int
+	// Seed – зерно генератора перестановок, чтобы сигнатуры воспроизводились между
+	// запусками и машинами. 0 – случайное зерно; выбранное значение сохраняется в MinHash.Seed.
+	Seed int64
+	// BBits включает хранение только младших b бит каждого минимума (b = 1, 2, 4 или 8)
+	// в упакованном виде. Сходство оценивается с поправкой Ли–Кёнига. 0 – все 64 бита.
+	BBits int
+	// OnePermutation включает one-permutation hashing: каждый элемент хэшируется один раз,
+	// а сигнатура собирается из минимумов HashFunctions корзин с densification пустых корзин.
+	OnePermutation bool
+	// Workers – число горутин, которые строят сигнатуры документов и бакеты полос.
+	// Результат совпадает с последовательным построением.
+	Workers int
+	// IDs – внешние идентификаторы документов в порядке множеств, по умолчанию "0", "1", ...
+	// Идентификаторы должны быть непустыми и уникальными, их число – совпадать с числом документов.
+	IDs []string
+	// SketchPrecision включает хранение скетча HyperLogLog с 2^p регистрами для каждого
+	// документа: EstimateUnion и EstimateIntersection объединяют скетчи вместо размеров.
+	SketchPrecision int
+	// Tokenizer нарезает тексты на шинглы в NewMinHashFromTexts, AddText и QueryText.
+	Tokenizer *tokenizer.Tokenizer
+}
+
+// validate проверяет параметры индекса, который будет построен по docs документам.
+func (c Config) validate(docs int) error {
+	if c.HashFunctions < 1 {
+		return fmt.Errorf("%w: need at least one hash function, got %d", ErrInvalidConfig, c.HashFunctions)
+	}
+	if c.Bands < 1 || c.Bands > c.HashFunctions {
+		return fmt.Errorf("%w: bands must be in [1, %d], got %d", ErrInvalidConfig, c.HashFunctions, c.Bands)
+	}
+	if c.HashFunctions%c.Bands != 0 {
+		return fmt.Errorf("%w: %d hash functions are not divisible into %d bands",
+			ErrInvalidConfig, c.HashFunctions, c.Bands)
+	}
+	if !validBBits(c.BBits) {
+		return fmt.Errorf("%w: b-bit MinHash supports 1, 2, 4 or 8 bits, got %d", ErrInvalidConfig, c.BBits)
+	}
+	if c.SketchPrecision != 0 && (c.SketchPrecision < hyperloglog.MinPrecision || c.SketchPrecision > hyperloglog.MaxPrecision) {
+		return fmt.Errorf("%w: sketch precision must be in [%d, %d], got %d",
+			ErrInvalidConfig, hyperloglog.MinPrecision, hyperloglog.MaxPrecision, c.SketchPrecision)
+	}
+	if c.IDs != nil {
+		if len(c.IDs) != docs {
+			return fmt.Errorf("%w: got %d IDs for %d documents", ErrInvalidConfig, len(c.IDs), docs)
+		}
+		seen := make(map[string]bool, len(c.IDs))
+		for _, id := range c.IDs {
+			if id == "" {
+				return fmt.Errorf("%w: %w", ErrInvalidConfig, ErrEmptyID)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: duplicate document ID %q", ErrInvalidConfig, id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
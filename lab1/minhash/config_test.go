@@ -0,0 +1,44 @@
+package minhash
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestConfigValidate проверяет отказ строить индекс с недопустимыми параметрами
+func TestConfigValidate(t *testing.T) {
+	sets := [][]string{{"a"}, {"b"}}
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no hash functions", Config{HashFunctions: 0, Bands: 1}},
+		{"no bands", Config{HashFunctions: 16, Bands: 0}},
+		{"too many bands", Config{HashFunctions: 16, Bands: 32}},
+		{"not divisible", Config{HashFunctions: 100, Bands: 30}},
+		{"b-bit", Config{HashFunctions: 16, Bands: 4, BBits: 3}},
+		{"sketch precision", Config{HashFunctions: 16, Bands: 4, SketchPrecision: 2}},
+		{"ID count", Config{HashFunctions: 16, Bands: 4, IDs: []string{"a"}}},
+		{"duplicate IDs", Config{HashFunctions: 16, Bands: 4, IDs: []string{"a", "a"}}},
+		{"empty ID", Config{HashFunctions: 16, Bands: 4, IDs: []string{"a", ""}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewMinHash(test.cfg, sets); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig, got %v", err)
+			}
+			if _, err := NewMinHashFromTexts(test.cfg, []string{"a", "b"}); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig from texts, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewMinHashForThreshold(Config{}, 0.8, 0.5, 0.5, sets); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for threshold constructor, got %v", err)
+	}
+
+	mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4}, sets)
+	if mh.Seed == 0 {
+		t.Error("Expected random non-zero seed")
+	}
+}
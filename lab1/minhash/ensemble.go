@@ -0,0 +1,202 @@
+package minhash
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Ensemble – LSH Ensemble (Zhu, Nargesian, Pu, Miller, 2016) для поиска по вложенности:
+// доле |Q ∩ X| / |Q| элементов запроса Q, содержащихся в документе X.
+// Вложенность t при размере документа x соответствует сходству Жаккара
+// t*q / (x + q - t*q), которое сильно зависит от x, поэтому документы делятся на
+// партиции равной численности по размеру множеств. Для каждой партиции по её верхней
+// границе размера подбирается своё число деревьев b и длина префикса r LSH Forest,
+// минимизирующие взвешенную сумму ложноположительной и ложноотрицательной площадей
+// по вложенности. Ensemble строится по снимку MinHash и не отслеживает последующие Add и Remove.
+type Ensemble struct {
+	FPWeight, FNWeight float64
+
+	mh         *MinHash
+	partitions []ensemblePartition
+
+	// params кэширует подобранные (b, r) по партиции и квантованным размеру запроса
+	// и порогу: подбор интегрирует S-кривую для всех сочетаний и дороже самого поиска.
+	mu     sync.Mutex
+	params map[ensembleParamsKey][2]int
+}
+
+// Размер запроса округляется до ближайшей точки логарифмической сетки с шагом 2^(1/8),
+// порог – вниз до сотых (меньший порог не снижает полноту). Поэтому ключей кэша не больше
+// partitions * 8 * 64 * 101, сколько бы разных запросов ни было.
+const (
+	ensembleSizeSteps      = 8
+	ensembleThresholdSteps = 100
+)
+
+// ensembleParamsKey – партиция и номера точек сетки размера запроса и порога.
+type ensembleParamsKey struct {
+	partition, size, threshold int
+}
+
+type ensemblePartition struct {
+	// upper – наибольший размер множества в партиции.
+	upper  int
+	forest *Forest
+}
+
+// NewEnsemble делит документы mh на partitions партиций по размеру множеств
+// и строит для каждой LSH Forest по уже вычисленным сигнатурам. Веса fpWeight и fnWeight
+// задают цену ложноположительных и ложноотрицательных кандидатов, как в OptimalParams.
+func NewEnsemble(mh *MinHash, partitions int, fpWeight, fnWeight float64) *Ensemble {
+	var slots []int
+	for slot, sig := range mh.Signatures {
+		if sig != nil && mh.Sizes[slot] > 0 {
+			slots = append(slots, slot)
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return mh.Sizes[slots[i]] < mh.Sizes[slots[j]] })
+
+	if partitions < 1 {
+		partitions = 1
+	}
+	if partitions > len(slots) {
+		partitions = len(slots)
+	}
+
+	e := &Ensemble{
+		FPWeight: fpWeight,
+		FNWeight: fnWeight,
+		mh:       mh,
+		params:   make(map[ensembleParamsKey][2]int),
+	}
+	for p := 0; p < partitions; p++ {
+		part := slots[p*len(slots)/partitions : (p+1)*len(slots)/partitions]
+		e.partitions = append(e.partitions, ensemblePartition{
+			upper:  mh.Sizes[part[len(part)-1]],
+			forest: newForest(mh, part),
+		})
+	}
+	return e
+}
+
+// QueryContainment возвращает документы, оценка вложенности set в которые не меньше t.
+// Поле Similarity результата содержит оценку вложенности; результат отсортирован по убыванию.
+func (e *Ensemble) QueryContainment(set []string, t float64) []QueryResult {
+	return e.query(e.mh.generateSignature(set), distinctCount(set), t)
+}
+
+// QueryTextContainment работает как QueryContainment для текста, нарезанного токенизатором индекса.
+func (e *Ensemble) QueryTextContainment(text string, t float64) []QueryResult {
+	sig, size := e.mh.textSignature(text)
+	return e.query(sig, size, t)
+}
+
+func (e *Ensemble) query(sig []uint64, size int, t float64) []QueryResult {
+	if size == 0 {
+		return nil
+	}
+	sig = e.mh.compact(sig)
+
+	seen := make(map[int]bool)
+	var slots []int
+	for p, part := range e.partitions {
+		// Документ меньше t*size элементов не может содержать долю t запроса.
+		if float64(part.upper) < t*float64(size) {
+			continue
+		}
+		trees, rows := e.partitionParams(p, size, t)
+		slots = part.forest.candidates(part.forest.keys(sig), trees, rows, seen, slots)
+	}
+
+	containment := make(map[int]float64)
+	var matches []int
+	for _, slot := range slots {
+		if c := e.mh.containment(sig, size, slot); c >= t {
+			containment[slot] = c
+			matches = append(matches, slot)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if containment[matches[i]] != containment[matches[j]] {
+			return containment[matches[i]] > containment[matches[j]]
+		}
+		return matches[i] < matches[j]
+	})
+
+	result := make([]QueryResult, len(matches))
+	for i, slot := range matches {
+		result[i] = QueryResult{ID: e.mh.IDs[slot], Similarity: containment[slot]}
+	}
+	return result
+}
+
+// partitionParams возвращает число деревьев и длину префикса партиции p для запроса
+// из size элементов и порога t, подобранные для ближайших точек сетки.
+func (e *Ensemble) partitionParams(p, size int, t float64) (trees, rows int) {
+	key := ensembleParamsKey{
+		partition: p,
+		size:      int(math.Round(math.Log2(float64(size)) * ensembleSizeSteps)),
+		threshold: int(math.Floor(math.Min(math.Max(t, 0), 1)*ensembleThresholdSteps + 1e-9)),
+	}
+	e.mu.Lock()
+	params, ok := e.params[key]
+	e.mu.Unlock()
+	if ok {
+		return params[0], params[1]
+	}
+
+	// Подбор идёт без блокировки, чтобы не задерживать другие запросы. Параллельные
+	// запросы с одним ключом могут подобрать параметры дважды, но результат одинаков.
+	forest := e.partitions[p].forest
+	q := max(1, int(math.Round(math.Exp2(float64(key.size)/ensembleSizeSteps))))
+	params[0], params[1] = containmentParams(float64(key.threshold)/ensembleThresholdSteps, q,
+		e.partitions[p].upper, len(forest.trees), forest.depth, e.FPWeight, e.FNWeight)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if cached, ok := e.params[key]; ok {
+		return cached[0], cached[1]
+	}
+	e.params[key] = params
+	return params[0], params[1]
+}
+
+// containment оценивает вложенность запроса из size элементов в документ slot
+// через оценку сходства Жаккара J: |Q ∩ X| = J (|Q| + |X|) / (1 + J).
+func (mh *MinHash) containment(sig []uint64, size, slot int) float64 {
+	j := mh.compare(sig, mh.Signatures[slot], size, mh.Sizes[slot])
+	intersection := j * float64(size+mh.Sizes[slot]) / (1 + j)
+	return math.Min(1, intersection/float64(size))
+}
+
+// containmentToJaccard переводит вложенность c запроса из q элементов в документ
+// из x элементов в сходство Жаккара.
+func containmentToJaccard(c float64, q, x int) float64 {
+	return c * float64(q) / (float64(x) + float64(q) - c*float64(q))
+}
+
+// containmentIntegrationSteps – число отрезков при подборе параметров партиции: подбор
+// выполняется при каждом запросе, а S-кривая гладкая, поэтому точности 1000 отрезков не нужно.
+const containmentIntegrationSteps = 64
+
+// containmentParams подбирает число деревьев и длину префикса для партиции с верхней
+// границей размера upper, минимизируя взвешенную сумму ложноположительной и
+// ложноотрицательной площадей по вложенности относительно порога t.
+func containmentParams(t float64, q, upper, maxTrees, maxRows int, fpWeight, fnWeight float64) (trees, rows int) {
+	minError := math.Inf(1)
+	for b := 1; b <= maxTrees; b++ {
+		for r := 1; r <= maxRows; r++ {
+			probability := func(c float64) float64 {
+				return CollisionProbability(containmentToJaccard(c, q, upper), b, r)
+			}
+			fp := integrateSteps(probability, 0, t, containmentIntegrationSteps)
+			fn := integrateSteps(func(c float64) float64 { return 1 - probability(c) }, t, 1, containmentIntegrationSteps)
+			if err := fpWeight*fp + fnWeight*fn; err < minError {
+				minError = err
+				trees, rows = b, r
+			}
+		}
+	}
+	return trees, rows
+}
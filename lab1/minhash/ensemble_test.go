@@ -0,0 +1,82 @@
+package minhash
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEnsembleContainment проверяет поиск документов, содержащих короткий запрос
+func TestEnsembleContainment(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_1000.text")
+	mh := mustMinHash(t, Config{HashFunctions: 256, Bands: 64, Seed: 1}, sets)
+	ensemble := NewEnsemble(mh, 8, 0.3, 0.7)
+
+	found, jaccardFound, queries := 0, 0, 0
+	start := time.Now()
+	for q := 0; q < len(sets); q += 20 {
+		// Короткий фрагмент документа целиком содержится в нём, но сходство Жаккара мало.
+		query := sets[q][:len(sets[q])/3]
+		queries++
+
+		for _, rec := range ensemble.QueryContainment(query, 0.8) {
+			if rec.Similarity < 0.8 {
+				t.Fatalf("Result %v below threshold", rec)
+			}
+			if rec.ID == fmt.Sprint(q) {
+				found++
+			}
+		}
+		for _, rec := range mh.Query(query, 0.8) {
+			if rec.ID == fmt.Sprint(q) {
+				jaccardFound++
+			}
+		}
+	}
+	t.Logf("Containment recall %d/%d, Jaccard query %d/%d, %v per query",
+		found, queries, jaccardFound, queries, time.Since(start)/time.Duration(queries))
+
+	if float64(found) < 0.9*float64(queries) {
+		t.Errorf("Expected containment recall >= 0.9, got %d/%d", found, queries)
+	}
+}
+
+func TestContainmentParams(t *testing.T) {
+	// Чем больше документы партиции относительно запроса, тем ниже эквивалентный порог
+	// Жаккара и тем короче должен быть префикс.
+	_, smallRows := containmentParams(0.8, 100, 100, 32, 4, 0.5, 0.5)
+	_, largeRows := containmentParams(0.8, 100, 5000, 32, 4, 0.5, 0.5)
+	if largeRows > smallRows {
+		t.Errorf("Expected shorter prefix for larger documents: %d > %d", largeRows, smallRows)
+	}
+
+	if j := containmentToJaccard(1, 10, 10); j != 1 {
+		t.Errorf("Expected Jaccard 1 for equal sets, got %v", j)
+	}
+}
+
+// TestEnsembleParamsCache проверяет, что кэш параметров растёт по сетке размеров и порогов,
+// а не по каждому запросу, и выдерживает параллельные запросы
+func TestEnsembleParamsCache(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+	mh := mustMinHash(t, Config{HashFunctions: 64, Bands: 16, Seed: 1}, sets)
+	ensemble := NewEnsemble(mh, 4, 0.5, 0.5)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for size := 1000; size < 2000; size++ {
+				ensemble.partitionParams(0, size, 0.8+float64(size%7)/1000)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Размеры 1000..1999 дают 9 точек логарифмической сетки, пороги 0.800..0.806 – одну.
+	if got := len(ensemble.params); got > 9 {
+		t.Errorf("Expected at most 9 cached parameter sets, got %d", got)
+	}
+}
@@ -0,0 +1,186 @@
+package minhash
+
+import "sort"
+
+// Forest – LSH Forest (Bawa, Condie, Ganesan, 2005) над сигнатурами MinHash.
+// Каждая полоса превращается в префиксное дерево: строки полосы образуют ключ,
+// и документы с совпадающим префиксом длины p лежат в дереве рядом. Дерево хранится
+// отсортированным массивом ключей, поиск префикса – двоичный. Запрос начинает с полной
+// длины полосы и укорачивает префикс, пока не наберёт k кандидатов, поэтому
+// top-k находится для любого уровня сходства без перестройки индекса.
+type Forest struct {
+	mh    *MinHash
+	depth int
+	trees [][]forestEntry
+}
+
+type forestEntry struct {
+	key  []uint64
+	slot int
+}
+
+// NewForest строит LSH Forest по непустым документам mh: по дереву на каждую полосу,
+// глубина дерева равна числу строк в полосе. Дальнейшие изменения следует вносить
+// через Forest.Add и Forest.Remove, чтобы деревья и бакеты mh оставались согласованы.
+func NewForest(mh *MinHash) *Forest {
+	var slots []int
+	for slot, sig := range mh.Signatures {
+		if sig != nil && mh.Sizes[slot] > 0 {
+			slots = append(slots, slot)
+		}
+	}
+	return newForest(mh, slots)
+}
+
+// newForest строит лес только по документам в позициях slots.
+func newForest(mh *MinHash, slots []int) *Forest {
+	f := &Forest{
+		mh:    mh,
+		depth: mh.Size / mh.Bands,
+		trees: make([][]forestEntry, mh.Bands),
+	}
+	for band := range f.trees {
+		for _, slot := range slots {
+			f.trees[band] = append(f.trees[band], forestEntry{key: f.key(mh.Signatures[slot], band), slot: slot})
+		}
+		tree := f.trees[band]
+		sort.Slice(tree, func(i, j int) bool { return comparePrefix(tree[i].key, tree[j].key, f.depth) < 0 })
+	}
+	return f
+}
+
+// key возвращает строки полосы band хранимой сигнатуры sig.
+func (f *Forest) key(sig []uint64, band int) []uint64 {
+	start := band * f.depth
+	if f.mh.BBits == 0 {
+		return sig[start : start+f.depth]
+	}
+	return unpackRange(sig, f.mh.BBits, start, start+f.depth)
+}
+
+// comparePrefix лексикографически сравнивает первые p значений ключей.
+func comparePrefix(a, b []uint64, p int) int {
+	for i := 0; i < p; i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Add добавляет документ в MinHash и во все деревья леса.
+func (f *Forest) Add(id string, set []string) error {
+	if err := f.mh.Add(id, set); err != nil {
+		return err
+	}
+	f.insert(id)
+	return nil
+}
+
+// AddText добавляет документ, заданный текстом, в MinHash и во все деревья леса.
+func (f *Forest) AddText(id string, text string) error {
+	if err := f.mh.AddText(id, text); err != nil {
+		return err
+	}
+	f.insert(id)
+	return nil
+}
+
+func (f *Forest) insert(id string) {
+	slot, _ := f.mh.Slot(id)
+	if f.mh.Sizes[slot] == 0 {
+		return
+	}
+	sig := f.mh.Signatures[slot]
+	for band, tree := range f.trees {
+		e := forestEntry{key: f.key(sig, band), slot: slot}
+		pos := sort.Search(len(tree), func(i int) bool { return comparePrefix(tree[i].key, e.key, f.depth) > 0 })
+		tree = append(tree, forestEntry{})
+		copy(tree[pos+1:], tree[pos:])
+		tree[pos] = e
+		f.trees[band] = tree
+	}
+}
+
+// Remove удаляет документ из деревьев леса и из MinHash.
+func (f *Forest) Remove(id string) error {
+	slot, exists := f.mh.Slot(id)
+	if !exists {
+		return ErrDocumentNotFound
+	}
+	// Пустые документы в деревья не попадают.
+	if f.mh.Sizes[slot] > 0 {
+		f.unlink(slot)
+	}
+	return f.mh.Remove(id)
+}
+
+// unlink удаляет документ slot из всех деревьев.
+func (f *Forest) unlink(slot int) {
+	for band, tree := range f.trees {
+		key := f.key(f.mh.Signatures[slot], band)
+		start := sort.Search(len(tree), func(i int) bool { return comparePrefix(tree[i].key, key, f.depth) >= 0 })
+		for i := start; i < len(tree) && comparePrefix(tree[i].key, key, f.depth) == 0; i++ {
+			if tree[i].slot == slot {
+				f.trees[band] = append(tree[:i], tree[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// QueryTopK возвращает не более k документов, наиболее похожих на set.
+func (f *Forest) QueryTopK(set []string, k int) []QueryResult {
+	return f.query(f.mh.generateSignature(set), distinctCount(set), k)
+}
+
+// QueryTextTopK возвращает не более k документов, наиболее похожих на текст.
+func (f *Forest) QueryTextTopK(text string, k int) []QueryResult {
+	sig, size := f.mh.textSignature(text)
+	return f.query(sig, size, k)
+}
+
+// query спускается по длине префикса от полной полосы к одной строке, пока во всех
+// деревьях вместе не наберётся k различных кандидатов, и ранжирует их по оценке сходства.
+func (f *Forest) query(sig []uint64, size, k int) []QueryResult {
+	if k <= 0 {
+		return nil
+	}
+	sig = f.mh.compact(sig)
+	keys := f.keys(sig)
+
+	seen := make(map[int]bool)
+	var slots []int
+	for p := f.depth; p > 0 && len(slots) < k; p-- {
+		slots = f.candidates(keys, len(f.trees), p, seen, slots)
+	}
+	return topK(f.mh.rank(sig, size, slots), k)
+}
+
+// keys возвращает ключи хранимой сигнатуры sig во всех деревьях.
+func (f *Forest) keys(sig []uint64) [][]uint64 {
+	keys := make([][]uint64, len(f.trees))
+	for band := range f.trees {
+		keys[band] = f.key(sig, band)
+	}
+	return keys
+}
+
+// candidates добавляет к slots документы, совпадающие с ключами запроса keys
+// по префиксу длины p хотя бы в одном из первых trees деревьев.
+func (f *Forest) candidates(keys [][]uint64, trees, p int, seen map[int]bool, slots []int) []int {
+	for band, tree := range f.trees[:trees] {
+		key := keys[band]
+		start := sort.Search(len(tree), func(i int) bool { return comparePrefix(tree[i].key, key, p) >= 0 })
+		for i := start; i < len(tree) && comparePrefix(tree[i].key, key, p) == 0; i++ {
+			if slot := tree[i].slot; !seen[slot] {
+				seen[slot] = true
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots
+}
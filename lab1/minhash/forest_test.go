@@ -0,0 +1,106 @@
+package minhash
+
+import "testing"
+
+// TestForestTopK проверяет, что лес находит k соседей там, где фиксированные полосы не находят никого
+func TestForestTopK(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_1000.text")
+	mh := mustMinHash(t, Config{HashFunctions: 128, Bands: 8, Seed: 1}, sets)
+	forest := NewForest(mh)
+
+	// Половина документа: сходство около 0.5, полосы из 16 строк почти никогда не совпадают.
+	query := sets[7][:len(sets[7])/2]
+	t.Logf("Banding found %d candidates", len(mh.QueryTopK(query, 5)))
+
+	result := forest.QueryTopK(query, 5)
+	if len(result) != 5 {
+		t.Fatalf("Expected 5 results, got %v", result)
+	}
+	if result[0].ID != "7" {
+		t.Errorf("Expected document 7 first, got %v", result)
+	}
+	for i := 1; i < len(result); i++ {
+		if result[i-1].Similarity < result[i].Similarity {
+			t.Fatalf("Results are not sorted: %v", result)
+		}
+	}
+}
+
+// TestForestRecall проверяет, что лес находит дубликаты и документы на разных уровнях сходства
+func TestForestRecall(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_1000.text")
+	expected := create_expected_result_from_file("data/articles_1000.test")
+
+	ids := make([]string, len(sets))
+	for num, id := range id_to_num {
+		ids[num] = id
+	}
+	mh := mustMinHash(t, Config{HashFunctions: 128, Bands: 16, Seed: 2, IDs: ids}, sets)
+	forest := NewForest(mh)
+
+	for pair := range expected {
+		slot, _ := mh.Slot(pair[0])
+		found := false
+		for _, rec := range forest.QueryTopK(sets[slot], 2) {
+			if rec.ID == pair[1] {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %s in top-2 for %s", pair[1], pair[0])
+		}
+	}
+
+	// Префикс документа с долей fraction его слов: сходство с исходным около fraction.
+	for _, fraction := range []float64{0.3, 0.5, 0.7, 0.9} {
+		misses, queries := 0, 0
+		for q := 0; q < len(sets); q += 25 {
+			query := sets[q][:int(float64(len(sets[q]))*fraction)]
+			queries++
+			found := false
+			for _, rec := range forest.QueryTopK(query, 5) {
+				if rec.ID == ids[q] {
+					found = true
+				}
+			}
+			if !found {
+				misses++
+			}
+		}
+		if misses > queries/10 {
+			t.Errorf("Fraction %.1f: source document missed in %d of %d queries", fraction, misses, queries)
+		}
+	}
+}
+
+// TestForestAddRemove проверяет согласованность деревьев при изменении документов
+func TestForestAddRemove(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+
+	for _, cfg := range []Config{{HashFunctions: 64, Bands: 8, Seed: 1}, {HashFunctions: 64, Bands: 8, Seed: 1, BBits: 8}} {
+		mh := mustMinHash(t, cfg, sets)
+		forest := NewForest(mh)
+
+		if err := forest.Remove("3"); err != nil {
+			t.Fatal(err)
+		}
+		if err := forest.Remove("3"); err != ErrDocumentNotFound {
+			t.Errorf("Expected ErrDocumentNotFound, got %v", err)
+		}
+		for _, rec := range forest.QueryTopK(sets[3], 10) {
+			if rec.ID == "3" {
+				t.Fatalf("Removed document returned: %v", rec)
+			}
+		}
+
+		if err := forest.Add("copy", sets[3]); err != nil {
+			t.Fatal(err)
+		}
+		if err := forest.Add("copy", sets[3]); err != ErrDocumentExists {
+			t.Errorf("Expected ErrDocumentExists, got %v", err)
+		}
+		if result := forest.QueryTopK(sets[3], 1); len(result) != 1 || result[0].ID != "copy" || result[0].Similarity != 1 {
+			t.Errorf("Expected added copy, got %v", result)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package minhash
+
+import "sort"
+
+// Pair – пара документов-кандидатов и оценка их сходства Жаккара.
+type Pair struct {
+	A, B       string
+	Similarity float64
+}
+
+// ID возвращает идентификатор документа в позиции slot ("" для удалённой позиции).
+func (mh *MinHash) ID(slot int) string {
+	if slot < 0 || slot >= len(mh.IDs) {
+		return ""
+	}
+	return mh.IDs[slot]
+}
+
+// Slot возвращает позицию документа id в Signatures.
+func (mh *MinHash) Slot(id string) (int, bool) {
+	slot, exists := mh.slots[id]
+	return slot, exists
+}
+
+// Len возвращает число документов в индексе.
+func (mh *MinHash) Len() int {
+	return len(mh.slots)
+}
+
+// SimilarPairs возвращает пары кандидатов из общих бакетов с оценкой сходства.
+// Пары упорядочены по позициям документов, внутри пары первым идёт более ранний документ.
+func (mh *MinHash) SimilarPairs() []Pair {
+	pairs := mh.FindSimilarPairs()
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	result := make([]Pair, len(pairs))
+	for i, pair := range pairs {
+		result[i] = Pair{A: mh.IDs[pair[0]], B: mh.IDs[pair[1]], Similarity: mh.estimatePair(pair[0], pair[1])}
+	}
+	return result
+}
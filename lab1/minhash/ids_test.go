@@ -0,0 +1,36 @@
+package minhash
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestIDs проверяет внешние идентификаторы в парах и их проверку при создании
+func TestIDs(t *testing.T) {
+	sets := [][]string{{"a", "b", "c"}, {"x", "y"}, {"a", "b", "c"}}
+	mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4, IDs: []string{"first", "other", "copy"}, Seed: 1}, sets)
+
+	pairs := mh.SimilarPairs()
+	if len(pairs) != 1 || pairs[0] != (Pair{A: "first", B: "copy", Similarity: 1}) {
+		t.Errorf("Expected pair {first copy 1}, got %v", pairs)
+	}
+
+	if err := mh.Add("", []string{"q"}); !errors.Is(err, ErrEmptyID) {
+		t.Errorf("Expected ErrEmptyID when adding an empty ID, got %v", err)
+	}
+	if err := mh.AddText("", "q"); !errors.Is(err, ErrEmptyID) {
+		t.Errorf("Expected ErrEmptyID when adding an empty ID as text, got %v", err)
+	}
+
+	for name, ids := range map[string][]string{
+		"duplicate": {"a", "b", "a"},
+		"count":     {"a", "b"},
+		"empty":     {"a", "", "c"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMinHash(Config{HashFunctions: 16, Bands: 4, IDs: ids}, sets); !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig for invalid IDs, got %v", err)
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package minhash
 
 import (
+	"errors"
 	"math"
+	"math/bits"
 	"math/rand"
-	"os"
+	"strconv"
 
 	"hash/fnv"
+
+	"lab1/hyperloglog"
+	"lab1/tokenizer"
 )
 
 type SetValueType interface {
@@ -38,92 +43,341 @@ const (
 	mersennePrime = (1 << 61) - 1
 )
 
-type hash_func func(uint64) uint64
+// permutation – функция (a*x + b) mod (2^61 - 1) из 2-универсального семейства.
+type permutation struct {
+	a, b uint64
+}
+
+// apply вычисляет перестановку для x < 2^61 - 1.
+func (p permutation) apply(x uint64) uint64 {
+	hi, lo := bits.Mul64(p.a, x)
+	// 2^64 ≡ 2^3 (mod 2^61 - 1), поэтому hi*2^64 + lo ≡ (hi<<3 | lo>>61) + (lo & (2^61 - 1)).
+	// a, x < 2^61, значит hi < 2^58 и сумма ниже помещается в uint64.
+	return modMersenne((hi<<3 | lo>>61) + (lo & mersennePrime) + p.b)
+}
 
-func create_permutation(a, b uint64) hash_func {
-	return func(x uint64) uint64 {
-		return (a*x + b%mersennePrime)
+// modMersenne приводит x по модулю 2^61 - 1.
+func modMersenne(x uint64) uint64 {
+	x = (x & mersennePrime) + (x >> 61)
+	if x >= mersennePrime {
+		x -= mersennePrime
 	}
+	return x
 }
 
 type MinHash struct {
-	Permutations []hash_func
+	Permutations []permutation
 	Signatures   [][]uint64
 	Size         int
 	Buckets      map[int]map[uint64][]int
 	Bands        int
+	// Seed – зерно генератора перестановок. Одинаковое зерно даёт одинаковые сигнатуры.
+	Seed int64
+	// BBits – сколько младших бит каждого минимума хранится в упакованной сигнатуре (0 – все 64).
+	BBits int
+	// Sizes – число различных элементов каждого документа.
+	Sizes []int
+	// OnePermutation включает one-permutation hashing: одна перестановка делится на Size корзин.
+	OnePermutation bool
+	// Workers – число горутин для построения сигнатур и бакетов (0 или 1 – последовательно).
+	Workers int
+	// Tokenizer нарезает тексты на шинглы в AddText и QueryText.
+	Tokenizer *tokenizer.Tokenizer
+	// IDs – внешний идентификатор документа в каждой позиции Signatures ("" для удалённых).
+	IDs []string
+	// slots – обратное отображение IDs: идентификатор -> позиция.
+	slots map[string]int
+	// SketchPrecision – точность скетчей HyperLogLog документов (0 – скетчи не строятся).
+	SketchPrecision int
+	// Sketches – скетч HyperLogLog каждого документа для оценки объединений и пересечений.
+	Sketches []*hyperloglog.Sketch
 }
 
-func NewMinHash(hash_func_cnt, bands int, sets [][]string) *MinHash {
-	sets_len := len(sets)
-	if sets_len <= 1 {
-		os.Exit(1)
+// NewMinHash строит индекс по множествам sets с параметрами cfg. Число документов может
+// быть любым, в том числе нулевым: пустой индекс пополняется через Add.
+// Пустое множество получает сигнатуру EmptySignatureValue во всех позициях (в режиме b-bit –
+// её младшие биты); такие документы не попадают в бакеты и похожи на любой документ с оценкой 0.
+func NewMinHash(cfg Config, sets [][]string) (*MinHash, error) {
+	obj, err := newMinHash(cfg, len(sets))
+	if err != nil {
+		return nil, err
+	}
+
+	parallelFor(len(sets), obj.Workers, func(set_id int) {
+		obj.Signatures[set_id] = obj.compact(obj.generateSignature(sets[set_id]))
+		obj.Sizes[set_id] = distinctCount(sets[set_id])
+		if obj.Sketches != nil {
+			obj.Sketches[set_id] = obj.setSketch(sets[set_id])
+		}
+	})
+
+	obj.bucketizeSignatures()
+
+	return obj, nil
+}
+
+// newMinHash проверяет cfg и создаёт индекс с перестановками и местом под sets_len сигнатур.
+func newMinHash(cfg Config, sets_len int) (*MinHash, error) {
+	if err := cfg.validate(sets_len); err != nil {
+		return nil, err
 	}
 
 	obj := &MinHash{
-		Size:         hash_func_cnt,
-		Permutations: make([]hash_func, hash_func_cnt),
-		Signatures:   make([][]uint64, len(sets)),
-		Buckets:      make(map[int]map[uint64][]int),
-		Bands:        bands,
+		Size:            cfg.HashFunctions,
+		Signatures:      make([][]uint64, sets_len),
+		Buckets:         make(map[int]map[uint64][]int),
+		Bands:           cfg.Bands,
+		Seed:            cfg.Seed,
+		BBits:           cfg.BBits,
+		Sizes:           make([]int, sets_len),
+		OnePermutation:  cfg.OnePermutation,
+		Workers:         cfg.Workers,
+		Tokenizer:       cfg.Tokenizer,
+		SketchPrecision: cfg.SketchPrecision,
+	}
+	for obj.Seed == 0 {
+		obj.Seed = rand.Int63()
+	}
+	if obj.SketchPrecision != 0 {
+		obj.Sketches = make([]*hyperloglog.Sketch, sets_len)
+	}
+	if cfg.IDs != nil {
+		obj.IDs = append([]string(nil), cfg.IDs...)
+	} else {
+		obj.IDs = make([]string, sets_len)
+		for i := range obj.IDs {
+			obj.IDs[i] = strconv.Itoa(i)
+		}
+	}
+	obj.slots = make(map[string]int, sets_len)
+	for slot, id := range obj.IDs {
+		obj.slots[id] = slot
 	}
 
 	obj.createPermutations()
 
-	for set_id, set := range sets {
-		obj.Signatures[set_id] = obj.generateSignature(set)
+	return obj, nil
+}
+
+func (mh *MinHash) createPermutations() {
+	n := mh.Size
+	if mh.OnePermutation {
+		n = 1
 	}
+	mh.Permutations = make([]permutation, n)
 
-	obj.bucketizeSignatures()
+	rng := rand.New(rand.NewSource(mh.Seed))
+	for i := 0; i < n; i++ {
+		a := rng.Uint64()%(mersennePrime-1) + 1
+		b := rng.Uint64() % mersennePrime
+		mh.Permutations[i] = permutation{a: a, b: b}
+	}
+}
 
-	return obj
+func (mh *MinHash) generateSignature(set []string) []uint64 {
+	signature := mh.emptySignature()
+	for _, elem := range set {
+		mh.updateSignature(signature, hash(elem))
+	}
+	mh.finishSignature(signature)
+	return signature
 }
 
-func (mh *MinHash) createPermutations() {
-	for i := 0; i < mh.Size; i++ {
-		a := rand.Uint64()%mersennePrime + 1
-		b := rand.Uint64() % mersennePrime
-		mh.Permutations[i] = create_permutation(a, b)
+// generateHashedSignature строит сигнатуру множества, элементы которого уже захэшированы.
+func (mh *MinHash) generateHashedSignature(set []uint64) []uint64 {
+	signature := mh.emptySignature()
+	for _, hashVal := range set {
+		mh.updateSignature(signature, hashVal)
 	}
+	mh.finishSignature(signature)
+	return signature
 }
 
-func (mh *MinHash) generateSignature(set []string) []uint64 {
+// distinctCount возвращает число различных элементов множества.
+func distinctCount(set []string) int {
+	seen := make(map[string]struct{}, len(set))
+	for _, elem := range set {
+		seen[elem] = struct{}{}
+	}
+	return len(seen)
+}
+
+// EmptySignatureValue – значение всех позиций сигнатуры пустого множества. Минимумы
+// непустых множеств меньше 2^61 - 1, поэтому с ним не совпадают.
+const EmptySignatureValue = math.MaxUint64
+
+func (mh *MinHash) emptySignature() []uint64 {
 	signature := make([]uint64, mh.Size)
 	for i := range signature {
-		signature[i] = math.MaxUint64
+		signature[i] = EmptySignatureValue
 	}
+	return signature
+}
 
-	for _, elem := range set {
-		hashVal := hash(elem)
-		for i, perm := range mh.Permutations {
-			minHash := perm(hashVal)
-			if minHash < signature[i] {
-				signature[i] = minHash
-			}
+// updateSignature учитывает в сигнатуре один элемент с хэшем hashVal.
+func (mh *MinHash) updateSignature(signature []uint64, hashVal uint64) {
+	x := modMersenne(hashVal)
+	if mh.OnePermutation {
+		mh.updateOnePermutation(signature, x)
+		return
+	}
+	for i, perm := range mh.Permutations {
+		minHash := perm.apply(x)
+		if minHash < signature[i] {
+			signature[i] = minHash
 		}
 	}
-	return signature
 }
 
+// bucketizeSignatures раскладывает по бакетам сигнатуры всех непустых документов.
 func (mh *MinHash) bucketizeSignatures() {
+	signatures := make([][]uint64, len(mh.Signatures))
+	for slot, sig := range mh.Signatures {
+		if mh.Sizes[slot] > 0 {
+			signatures[slot] = sig
+		}
+	}
+	bucketize(mh.Buckets, signatures, mh.Bands, mh.Workers, mh.bandKey)
+}
+
+// bucketize раскладывает сигнатуры по бакетам bands полос, ключ полосы считает key.
+// Полосы независимы и заполняются в workers горутинах; внутри полосы документы идут
+// по возрастанию номера, поэтому результат не зависит от числа горутин.
+// Пустые (удалённые) сигнатуры пропускаются.
+func bucketize(buckets map[int]map[uint64][]int, signatures [][]uint64, bands, workers int, key func(sig []uint64, band int) uint64) {
+	for band := 0; band < bands; band++ {
+		buckets[band] = make(map[uint64][]int)
+	}
+	parallelFor(bands, workers, func(band int) {
+		bandBuckets := buckets[band]
+		for i, sig := range signatures {
+			if sig == nil {
+				continue
+			}
+			bandSig := key(sig, band)
+			bandBuckets[bandSig] = append(bandBuckets[bandSig], i)
+		}
+	})
+}
+
+// bandKey возвращает ключ бакета полосы band для хранимой сигнатуры sig
+// (упакованной, если включён режим b-bit).
+func (mh *MinHash) bandKey(sig []uint64, band int) uint64 {
 	rows := mh.Size / mh.Bands
+	if mh.BBits == 0 {
+		return bandKey(sig, band, rows)
+	}
+	return hashBand(unpackRange(sig, mh.BBits, band*rows, band*rows+rows))
+}
+
+func bandKey(sig []uint64, band, rows int) uint64 {
+	start := band * rows
+	end := start + rows
+	return hashBand(sig[start:end])
+}
+
+var (
+	ErrDocumentExists   = errors.New("document already exists")
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrEmptyID – пустой идентификатор: "" обозначает удалённую позицию в IDs.
+	ErrEmptyID = errors.New("empty document ID")
+)
+
+// Add добавляет документ с идентификатором id в уже построенный индекс:
+// вычисляет сигнатуру существующими перестановками и раскладывает её по бакетам полос.
+// Документ занимает новую позицию в конце Signatures.
+func (mh *MinHash) Add(id string, set []string) error {
+	if err := mh.checkNewID(id); err != nil {
+		return err
+	}
+	mh.addSignature(id, mh.generateSignature(set), distinctCount(set), mh.setSketch(set))
+	return nil
+}
+
+// checkNewID проверяет, что идентификатор id непуст и свободен.
+func (mh *MinHash) checkNewID(id string) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+	if _, exists := mh.slots[id]; exists {
+		return ErrDocumentExists
+	}
+	return nil
+}
+
+// addSignature сохраняет сигнатуру документа id из size элементов и его скетч в новой позиции
+// и раскладывает сигнатуру по бакетам полос.
+func (mh *MinHash) addSignature(id string, sig []uint64, size int, sketch *hyperloglog.Sketch) {
+	slot := len(mh.Signatures)
+	sig = mh.compact(sig)
+	mh.Signatures = append(mh.Signatures, sig)
+	mh.Sizes = append(mh.Sizes, size)
+	mh.IDs = append(mh.IDs, id)
+	if mh.SketchPrecision != 0 {
+		mh.Sketches = append(mh.Sketches, sketch)
+	}
+	if mh.slots == nil {
+		mh.slots = make(map[string]int)
+	}
+	mh.slots[id] = slot
 
 	for band := 0; band < mh.Bands; band++ {
-		mh.Buckets[band] = make(map[uint64][]int)
-		for i, sig := range mh.Signatures {
-			start := band * rows
-			end := start + rows
-			bandSig := hashBand(sig[start:end])
-			mh.Buckets[band][bandSig] = append(mh.Buckets[band][bandSig], i)
+		if mh.Buckets[band] == nil {
+			mh.Buckets[band] = make(map[uint64][]int)
+		}
+		if size == 0 {
+			continue
 		}
+		bandSig := mh.bandKey(sig, band)
+		mh.Buckets[band][bandSig] = append(mh.Buckets[band][bandSig], slot)
 	}
 }
 
+// Remove удаляет документ из бакетов всех полос и освобождает его сигнатуру.
+// Позиции остальных документов не меняются.
+func (mh *MinHash) Remove(id string) error {
+	slot, exists := mh.slots[id]
+	if !exists {
+		return ErrDocumentNotFound
+	}
+
+	sig := mh.Signatures[slot]
+	for band := 0; band < mh.Bands && mh.Sizes[slot] > 0; band++ {
+		bandSig := mh.bandKey(sig, band)
+		candidates := mh.Buckets[band][bandSig]
+		for i, candidate := range candidates {
+			if candidate == slot {
+				candidates = append(candidates[:i], candidates[i+1:]...)
+				break
+			}
+		}
+		if len(candidates) == 0 {
+			delete(mh.Buckets[band], bandSig)
+		} else {
+			mh.Buckets[band][bandSig] = candidates
+		}
+	}
+	mh.Signatures[slot] = nil
+	mh.Sizes[slot] = 0
+	mh.IDs[slot] = ""
+	if mh.Sketches != nil {
+		mh.Sketches[slot] = nil
+	}
+	delete(mh.slots, id)
+	return nil
+}
+
+// FindSimilarPairs возвращает пары позиций документов; идентификаторы даёт ID или SimilarPairs.
 func (mh *MinHash) FindSimilarPairs() [][]int {
+	return findSimilarPairs(mh.Buckets)
+}
+
+// findSimilarPairs возвращает пары документов, попавших в общий бакет хотя бы одной полосы.
+func findSimilarPairs(buckets map[int]map[uint64][]int) [][]int {
 	var pairs [][]int
 	seen := make(map[[2]int]bool)
 
-	for _, bandBuckets := range mh.Buckets {
+	for _, bandBuckets := range buckets {
 		for _, candidates := range bandBuckets {
 			cand_len := len(candidates)
 			if cand_len < 2 {
@@ -132,10 +386,14 @@ func (mh *MinHash) FindSimilarPairs() [][]int {
 
 			for i := 0; i < len(candidates); i++ {
 				for j := i + 1; j < len(candidates); j++ {
+					// После Add номера в бакете могут идти не по возрастанию.
 					pair := [2]int{candidates[i], candidates[j]}
+					if pair[0] > pair[1] {
+						pair[0], pair[1] = pair[1], pair[0]
+					}
 					if !seen[pair] {
 						seen[pair] = true
-						pairs = append(pairs, []int{candidates[i], candidates[j]})
+						pairs = append(pairs, []int{pair[0], pair[1]})
 					}
 				}
 			}
@@ -144,6 +402,35 @@ func (mh *MinHash) FindSimilarPairs() [][]int {
 	return pairs
 }
 
+// estimateSimilarity оценивает сходство Жаккара как долю совпавших позиций сигнатур.
+func estimateSimilarity(a, b []uint64) float64 {
+	intersection := 0
+	for k := range a {
+		if a[k] == b[k] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a))
+}
+
+// estimatePair оценивает сходство Жаккара документов i и j.
+func (mh *MinHash) estimatePair(i, j int) float64 {
+	return mh.compare(mh.Signatures[i], mh.Signatures[j], mh.Sizes[i], mh.Sizes[j])
+}
+
+// compare оценивает сходство по хранимым сигнатурам множеств из sizeA и sizeB элементов.
+// Для b-bit сигнатур применяется поправка на случайные совпадения младших бит.
+// Сходство с пустым множеством равно 0, как у exactJaccard.
+func (mh *MinHash) compare(a, b []uint64, sizeA, sizeB int) float64 {
+	if sizeA == 0 || sizeB == 0 {
+		return 0
+	}
+	if mh.BBits == 0 {
+		return estimateSimilarity(a, b)
+	}
+	return bbitEstimate(bbitMatches(a, b, mh.BBits, mh.Size), mh.BBits, sizeA, sizeB)
+}
+
 func (mh *MinHash) FindSimilarPairsNoReturn() {
 	mh.FindSimilarPairs()
 }
@@ -154,15 +441,8 @@ func (mh *MinHash) Similarity() [][]float64 {
 	var result [][]float64
 
 	for _, pair := range pairs {
-		intersection := 0
-
-		for k := 0; k < mh.Size; k++ {
-			if mh.Signatures[pair[0]][k] == mh.Signatures[pair[1]][k] {
-				intersection++
-			}
-		}
-
-		result = append(result, []float64{float64(pair[0]), float64(pair[1]), float64(intersection) / float64(mh.Size)})
+		similarity := mh.estimatePair(pair[0], pair[1])
+		result = append(result, []float64{float64(pair[0]), float64(pair[1]), similarity})
 	}
 
 	return result
@@ -172,14 +452,7 @@ func (mh *MinHash) SimilarityForBench(pairs [][]int) {
 	var result [][]float64
 
 	for _, pair := range pairs {
-		intersection := 0
-
-		for k := 0; k < mh.Size; k++ {
-			if mh.Signatures[pair[0]][k] == mh.Signatures[pair[1]][k] {
-				intersection++
-			}
-		}
-
-		result = append(result, []float64{float64(pair[0]), float64(pair[1]), float64(intersection) / float64(mh.Size)})
+		similarity := mh.estimatePair(pair[0], pair[1])
+		result = append(result, []float64{float64(pair[0]), float64(pair[1]), similarity})
 	}
 }
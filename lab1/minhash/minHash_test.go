@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/big"
+	"math/rand"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -64,7 +67,7 @@ func TestBasicFunctionality(t *testing.T) {
 	}
 
 	// Создаём MinHash с 16 хеш-функциями и 4 полосами
-	mh := NewMinHash(16, 4, sets)
+	mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4}, sets)
 	result := mh.Similarity()
 
 	// Ожидаемые пары с максимальной схожестью (1.0)
@@ -107,7 +110,7 @@ func TestAllData(t *testing.T) {
 			sets, id_to_num := create_sets_from_file(data_set[1])
 			expected := create_expected_result_from_file(data_set[2])
 
-			mh := NewMinHash(16, 4, sets)
+			mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4}, sets)
 			result := mh.Similarity()
 
 			for _, rec := range result {
@@ -130,6 +133,219 @@ func TestAllData(t *testing.T) {
 	}
 }
 
+// TestAddRemove проверяет добавление и удаление документов после построения индекса
+func TestAddRemove(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_100.text")
+	ids := make([]string, len(sets))
+	for num, id := range id_to_num {
+		ids[num] = id
+	}
+
+	mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4, IDs: ids}, sets)
+	before := idPairsSet(mh.SimilarPairs())
+	removed := ids[0]
+	signature := mh.Signatures[0]
+
+	if err := mh.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	for band, bandBuckets := range mh.Buckets {
+		for _, candidates := range bandBuckets {
+			for _, slot := range candidates {
+				if slot == 0 {
+					t.Fatalf("Removed document still in band %d", band)
+				}
+			}
+		}
+	}
+	for pair := range idPairsSet(mh.SimilarPairs()) {
+		if pair[0] == removed || pair[1] == removed {
+			t.Fatalf("Removed document in pair %v", pair)
+		}
+	}
+	if err := mh.Remove(removed); err != ErrDocumentNotFound {
+		t.Errorf("Expected ErrDocumentNotFound, got %v", err)
+	}
+	if mh.Len() != len(sets)-1 {
+		t.Errorf("Expected %d documents, got %d", len(sets)-1, mh.Len())
+	}
+
+	if err := mh.Add(removed, sets[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := mh.Add(removed, sets[0]); err != ErrDocumentExists {
+		t.Errorf("Expected ErrDocumentExists, got %v", err)
+	}
+	slot, ok := mh.Slot(removed)
+	if !ok || mh.ID(slot) != removed {
+		t.Fatalf("Re-added document %s not found", removed)
+	}
+	for k := range signature {
+		if signature[k] != mh.Signatures[slot][k] {
+			t.Fatalf("Signature changed after re-adding: position %d", k)
+		}
+	}
+	after := idPairsSet(mh.SimilarPairs())
+	if len(before) != len(after) {
+		t.Fatalf("Expected %d pairs after re-adding, got %d", len(before), len(after))
+	}
+	for pair := range before {
+		if !after[pair] && !after[[2]string{pair[1], pair[0]}] {
+			t.Fatalf("Pair %v lost after re-adding", pair)
+		}
+	}
+
+	// Документ с новым идентификатором находит свой дубликат.
+	if err := mh.Add("copy", sets[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !idPairsSet(mh.SimilarPairs())[[2]string{removed, "copy"}] {
+		t.Errorf("Expected pair {%s, copy} for duplicate document", removed)
+	}
+}
+
+func idPairsSet(pairs []Pair) map[[2]string]bool {
+	result := make(map[[2]string]bool, len(pairs))
+	for _, pair := range pairs {
+		result[[2]string{pair.A, pair.B}] = true
+	}
+	return result
+}
+
+// TestPermutation сверяет модульную арифметику перестановок с math/big
+func TestPermutation(t *testing.T) {
+	p := new(big.Int).SetUint64(mersennePrime)
+	rng := rand.New(rand.NewSource(1))
+
+	cases := []permutation{{a: 1, b: 0}, {a: mersennePrime - 1, b: mersennePrime - 1}}
+	for i := 0; i < 1000; i++ {
+		cases = append(cases, permutation{a: rng.Uint64()%(mersennePrime-1) + 1, b: rng.Uint64() % mersennePrime})
+	}
+
+	for _, perm := range cases {
+		for _, x := range []uint64{0, 1, mersennePrime - 1, modMersenne(math.MaxUint64), modMersenne(rng.Uint64())} {
+			expected := new(big.Int).SetUint64(perm.a)
+			expected.Mul(expected, new(big.Int).SetUint64(x))
+			expected.Add(expected, new(big.Int).SetUint64(perm.b))
+			expected.Mod(expected, p)
+
+			if got := perm.apply(x); got != expected.Uint64() {
+				t.Fatalf("(%d*%d + %d) mod p: expected %v, got %d", perm.a, x, perm.b, expected, got)
+			}
+		}
+	}
+}
+
+// TestSeed проверяет воспроизводимость сигнатур при одинаковом зерне
+func TestSeed(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+
+	first := mustMinHash(t, Config{HashFunctions: 32, Bands: 8, Seed: 42}, sets)
+	second := mustMinHash(t, Config{HashFunctions: 32, Bands: 8, Seed: 42}, sets)
+	other := mustMinHash(t, Config{HashFunctions: 32, Bands: 8, Seed: 43}, sets)
+
+	if !reflect.DeepEqual(first.Signatures, second.Signatures) {
+		t.Error("Expected identical signatures for the same seed")
+	}
+	if reflect.DeepEqual(first.Signatures, other.Signatures) {
+		t.Error("Expected different signatures for different seeds")
+	}
+}
+
+// TestUnbiasedEstimate проверяет, что средняя оценка сходства совпадает с точным коэффициентом Жаккара
+func TestUnbiasedEstimate(t *testing.T) {
+	const (
+		hashes = 64
+		trials = 200
+	)
+
+	for _, overlap := range []int{10, 50, 90} {
+		// Множества по 100 элементов с общими overlap элементами.
+		a := make([]string, 0, 100)
+		b := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			a = append(a, fmt.Sprintf("e%d", i))
+			b = append(b, fmt.Sprintf("e%d", i+100-overlap))
+		}
+		jaccard := float64(overlap) / float64(200-overlap)
+
+		var total float64
+		for seed := 0; seed < trials; seed++ {
+			mh := mustMinHash(t, Config{HashFunctions: hashes, Bands: 1, Seed: int64(seed) + 1}, [][]string{a, b})
+			total += estimateSimilarity(mh.Signatures[0], mh.Signatures[1])
+		}
+		mean := total / trials
+
+		// Стандартная ошибка среднего по trials независимым оценкам.
+		stdErr := math.Sqrt(jaccard * (1 - jaccard) / (hashes * trials))
+		if math.Abs(mean-jaccard) > 4*stdErr {
+			t.Errorf("Overlap %d: expected mean %.4f, got %.4f (std err %.4f)", overlap, jaccard, mean, stdErr)
+		}
+		t.Logf("Overlap %d: jaccard=%.4f mean estimate=%.4f", overlap, jaccard, mean)
+	}
+}
+
+// mustMinHash строит индекс и прерывает тест, если параметры отвергнуты.
+func mustMinHash(tb testing.TB, cfg Config, sets [][]string) *MinHash {
+	tb.Helper()
+	mh, err := NewMinHash(cfg, sets)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return mh
+}
+
+// TestEmptySets проверяет пустой индекс, индекс из одного документа и пустые множества
+func TestEmptySets(t *testing.T) {
+	for _, cfg := range []Config{
+		{HashFunctions: 16, Bands: 4, Seed: 1},
+		{HashFunctions: 16, Bands: 4, Seed: 1, BBits: 4},
+		{HashFunctions: 16, Bands: 4, Seed: 1, OnePermutation: true},
+	} {
+		mh := mustMinHash(t, cfg, nil)
+		if mh.Len() != 0 || len(mh.FindSimilarPairs()) != 0 {
+			t.Fatalf("Expected empty index, got %d documents", mh.Len())
+		}
+
+		single := mustMinHash(t, cfg, [][]string{{"a", "b"}})
+		if result := single.Query([]string{"a", "b"}, 1); len(result) != 1 || result[0].ID != "0" {
+			t.Errorf("Expected single document to match itself, got %v", result)
+		}
+
+		mh = mustMinHash(t, cfg, [][]string{{}, {}, {"a", "b"}})
+		want := mh.compact(mh.emptySignature())
+		for _, v := range mh.emptySignature() {
+			if v != EmptySignatureValue {
+				t.Fatalf("Expected sentinel %x, got %x", uint64(EmptySignatureValue), v)
+			}
+		}
+		if !reflect.DeepEqual(mh.Signatures[0], want) || !reflect.DeepEqual(mh.Signatures[1], want) {
+			t.Fatalf("Expected sentinel signature for empty sets, got %v", mh.Signatures[0])
+		}
+		if pairs := mh.FindSimilarPairs(); len(pairs) != 0 {
+			t.Errorf("Empty documents must not be paired, got %v", pairs)
+		}
+		if result := mh.Query(nil, 0); len(result) != 0 {
+			t.Errorf("Empty query must not match, got %v", result)
+		}
+		if sim := mh.estimatePair(0, 1); sim != 0 {
+			t.Errorf("Expected similarity 0 between empty sets, got %v", sim)
+		}
+
+		if err := mh.Add("empty", nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"0", "empty"} {
+			if err := mh.Remove(id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if result := mh.Query([]string{"a", "b"}, 1); len(result) != 1 || result[0].ID != "2" {
+			t.Errorf("Expected document 2, got %v", result)
+		}
+	}
+}
+
 // create_sets_from_file читает файл, где каждая строка содержит идентификатор и набор слов,
 // и возвращает срез наборов и мапу соответствия индекса идентификатору.
 func create_sets_from_file(filepath string) ([][]string, map[int]string) {
@@ -213,7 +429,7 @@ func BenchmarkFull(b *testing.B) {
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
 		measureTime(b, file, func() {
-			mh := NewMinHash(100, 10, sets)
+			mh := mustMinHash(b, Config{HashFunctions: 100, Bands: 10}, sets)
 			mh.Similarity()
 		})
 	}
@@ -225,40 +441,50 @@ func BenchmarkCreateMinHashOnly(b *testing.B) {
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
 		measureTime(b, file, func() {
-			NewMinHash(100, 10, sets)
+			mustMinHash(b, Config{HashFunctions: 100, Bands: 10}, sets)
 		})
 	}
 }
 
 // BenchmarkCreatePermutations измеряет время генерации перестановок (хеш-функций)
 func BenchmarkCreatePermutations(b *testing.B) {
-	mh := &MinHash{Size: 100, Permutations: make([]hash_func, 100)}
+	mh := &MinHash{Size: 100}
 	measureTime(b, "create permutations", mh.createPermutations)
 }
 
-// BenchmarkGenerateSignature измеряет время генерации сигнатур для каждого набора
+// BenchmarkGenerateSignature измеряет время генерации сигнатур для каждого набора:
+// k перестановок на элемент против one-permutation hashing
 func BenchmarkGenerateSignature(b *testing.B) {
 	files := []string{"data/articles_100.text", "data/articles_1000.text", "data/articles_2500.text"}
+	modes := []struct {
+		name           string
+		onePermutation bool
+	}{
+		{"k-permutations", false},
+		{"one-permutation", true},
+	}
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
-		obj := &MinHash{
-			Size:         100,
-			Permutations: make([]hash_func, 100),
-			Signatures:   make([][]uint64, len(sets)),
-		}
-		obj.createPermutations()
-
-		b.Run(fmt.Sprintf("File-%s", file), func(b *testing.B) {
-			durations := make([]time.Duration, 0, len(sets))
-			for setID, set := range sets {
-				startOp := time.Now()
-				obj.Signatures[setID] = obj.generateSignature(set)
-				opDuration := time.Since(startOp)
-				durations = append(durations, opDuration)
+		for _, mode := range modes {
+			obj := &MinHash{
+				Size:           100,
+				Signatures:     make([][]uint64, len(sets)),
+				OnePermutation: mode.onePermutation,
 			}
-			mean, q1, median, q3 := computeStats(durations)
-			b.Logf("GenerateSignature для файла %s: mean=%v, q1=%v, median=%v, q3=%v", file, mean, q1, median, q3)
-		})
+			obj.createPermutations()
+
+			b.Run(fmt.Sprintf("%s/File-%s", mode.name, file), func(b *testing.B) {
+				durations := make([]time.Duration, 0, len(sets))
+				for setID, set := range sets {
+					startOp := time.Now()
+					obj.Signatures[setID] = obj.generateSignature(set)
+					opDuration := time.Since(startOp)
+					durations = append(durations, opDuration)
+				}
+				mean, q1, median, q3 := computeStats(durations)
+				b.Logf("GenerateSignature (%s) для файла %s: mean=%v, q1=%v, median=%v, q3=%v", mode.name, file, mean, q1, median, q3)
+			})
+		}
 	}
 }
 
@@ -268,15 +494,16 @@ func BenchmarkBucketizeSignatures(b *testing.B) {
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
 		obj := &MinHash{
-			Size:         100,
-			Permutations: make([]hash_func, 100),
-			Signatures:   make([][]uint64, len(sets)),
-			Buckets:      make(map[int]map[uint64][]int),
-			Bands:        10,
+			Size:       100,
+			Signatures: make([][]uint64, len(sets)),
+			Buckets:    make(map[int]map[uint64][]int),
+			Bands:      10,
+			Sizes:      make([]int, len(sets)),
 		}
 		obj.createPermutations()
 		for setID, set := range sets {
 			obj.Signatures[setID] = obj.generateSignature(set)
+			obj.Sizes[setID] = distinctCount(set)
 		}
 		measureTime(b, file, obj.bucketizeSignatures)
 	}
@@ -287,7 +514,7 @@ func BenchmarkFindSimilarPairs(b *testing.B) {
 	files := []string{"data/articles_100.text", "data/articles_1000.text", "data/articles_2500.text"}
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
-		mh := NewMinHash(100, 10, sets)
+		mh := mustMinHash(b, Config{HashFunctions: 100, Bands: 10}, sets)
 		measureTime(b, file, mh.FindSimilarPairsNoReturn)
 	}
 }
@@ -297,7 +524,7 @@ func BenchmarkCalculateSimilarity(b *testing.B) {
 	files := []string{"data/articles_100.text", "data/articles_1000.text", "data/articles_2500.text"}
 	for _, file := range files {
 		sets, _ := create_sets_from_file(file)
-		mh := NewMinHash(100, 10, sets)
+		mh := mustMinHash(b, Config{HashFunctions: 100, Bands: 10}, sets)
 		pairs := mh.FindSimilarPairs()
 		measureTime(b, file, func() {
 			mh.SimilarityForBench(pairs)
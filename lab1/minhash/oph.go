@@ -0,0 +1,58 @@
+package minhash
+
+import (
+	"math"
+	"math/bits"
+)
+
+// updateOnePermutation учитывает элемент x в режиме one-permutation hashing:
+// значение единственной перестановки определяет корзину, в которой хранится минимум.
+func (mh *MinHash) updateOnePermutation(signature []uint64, x uint64) {
+	v := mh.Permutations[0].apply(x)
+	// v < 2^61, поэтому корзина floor(v*Size / 2^61) – старшее слово (v<<3)*Size.
+	bin, _ := bits.Mul64(v<<3, uint64(mh.Size))
+	if v < signature[bin] {
+		signature[bin] = v
+	}
+}
+
+// finishSignature завершает построение сигнатуры: в режиме one-permutation hashing
+// заполняет пустые корзины оптимальной densification.
+func (mh *MinHash) finishSignature(signature []uint64) {
+	if mh.OnePermutation {
+		mh.densify(signature)
+	}
+}
+
+// densify заполняет пустые корзины значениями непустых (Shrivastava, "Optimal Densification
+// for Fast and Accurate Minwise Hashing", 2017): для пустой корзины i перебираются корзины
+// h(i, 1), h(i, 2), ... пока не встретится изначально непустая. Хэш h зависит только от
+// номера корзины, попытки и Seed, поэтому одинаково работает для всех документов.
+// Сигнатура пустого множества не меняется.
+func (mh *MinHash) densify(signature []uint64) {
+	filled := make([]bool, len(signature))
+	empty := 0
+	for i, v := range signature {
+		filled[i] = v != math.MaxUint64
+		if !filled[i] {
+			empty++
+		}
+	}
+	if empty == 0 || empty == len(signature) {
+		return
+	}
+
+	for i := range signature {
+		if filled[i] {
+			continue
+		}
+		for attempt := uint64(1); ; attempt++ {
+			state := uint64(mh.Seed) ^ uint64(i)<<32 ^ attempt
+			j, _ := bits.Mul64(splitmix64(&state), uint64(len(signature)))
+			if filled[j] {
+				signature[i] = signature[j]
+				break
+			}
+		}
+	}
+}
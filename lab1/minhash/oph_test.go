@@ -0,0 +1,81 @@
+package minhash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestDensify проверяет заполнение пустых корзин
+func TestDensify(t *testing.T) {
+	mh := mustMinHash(t, Config{HashFunctions: 64, Bands: 8, Seed: 3, OnePermutation: true}, [][]string{{"a", "b", "c"}, {"a", "b", "d"}})
+
+	if len(mh.Permutations) != 1 {
+		t.Fatalf("Expected one permutation, got %d", len(mh.Permutations))
+	}
+	for _, sig := range mh.Signatures {
+		for i, v := range sig {
+			if v == math.MaxUint64 {
+				t.Fatalf("Bin %d left empty after densification", i)
+			}
+		}
+	}
+
+	empty := mh.generateSignature(nil)
+	for i, v := range empty {
+		if v != math.MaxUint64 {
+			t.Fatalf("Empty set: bin %d = %d, expected sentinel", i, v)
+		}
+	}
+}
+
+// TestOnePermutationEstimate проверяет несмещённость оценки сходства при one-permutation hashing
+func TestOnePermutationEstimate(t *testing.T) {
+	const (
+		hashes = 64
+		trials = 200
+	)
+
+	for _, overlap := range []int{20, 60} {
+		a := make([]string, 0, 100)
+		b := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			a = append(a, fmt.Sprintf("e%d", i))
+			b = append(b, fmt.Sprintf("e%d", i+100-overlap))
+		}
+		jaccard := float64(overlap) / float64(200-overlap)
+
+		var total float64
+		for seed := 0; seed < trials; seed++ {
+			mh := mustMinHash(t, Config{HashFunctions: hashes, Bands: 1, Seed: int64(seed) + 1, OnePermutation: true}, [][]string{a, b})
+			total += estimateSimilarity(mh.Signatures[0], mh.Signatures[1])
+		}
+		mean := total / trials
+
+		stdErr := math.Sqrt(jaccard * (1 - jaccard) / (hashes * trials))
+		if math.Abs(mean-jaccard) > 4*stdErr {
+			t.Errorf("Overlap %d: expected mean %.4f, got %.4f (std err %.4f)", overlap, jaccard, mean, stdErr)
+		}
+		t.Logf("Overlap %d: jaccard=%.4f mean estimate=%.4f", overlap, jaccard, mean)
+	}
+}
+
+// TestOnePermutationArticles проверяет поиск дубликатов при one-permutation hashing
+func TestOnePermutationArticles(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_1000.text")
+	expected := create_expected_result_from_file("data/articles_1000.test")
+
+	mh := mustMinHash(t, Config{HashFunctions: 100, Bands: 20, Seed: 1, OnePermutation: true}, sets)
+	found := 0
+	for _, rec := range mh.Similarity() {
+		pair := [2]string{id_to_num[int(rec[0])], id_to_num[int(rec[1])]}
+		if expected[pair] != 0 && rec[2] > 0.85 {
+			found++
+		} else if expected[pair] == 0 && rec[2] > 0.95 {
+			t.Errorf("Pair %v: similarity %v, expected меньше", pair, rec[2])
+		}
+	}
+	if found != len(expected) {
+		t.Errorf("Expected %d duplicate pairs, found %d", len(expected), found)
+	}
+}
@@ -0,0 +1,37 @@
+package minhash
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// parallelFor вызывает fn(i) для каждого i из [0, n), распределяя номера между workers
+// горутинами. При workers <= 1 вызовы идут последовательно в текущей горутине.
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
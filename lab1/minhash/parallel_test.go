@@ -0,0 +1,79 @@
+package minhash
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// TestParallelBuild проверяет, что параллельное построение совпадает с последовательным
+func TestParallelBuild(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_1000.text")
+
+	configs := []struct {
+		name string
+		cfg  Config
+	}{
+		{"full", Config{HashFunctions: 100, Bands: 20, Seed: 11}},
+		{"b-bit", Config{HashFunctions: 100, Bands: 20, Seed: 11, BBits: 2}},
+		{"one-permutation", Config{HashFunctions: 100, Bands: 20, Seed: 11, OnePermutation: true}},
+	}
+
+	for _, config := range configs {
+		sequential := mustMinHash(t, config.cfg, sets)
+
+		for _, workers := range []int{2, 3, 8} {
+			t.Run(fmt.Sprintf("%s/workers-%d", config.name, workers), func(t *testing.T) {
+				cfg := config.cfg
+				cfg.Workers = workers
+				parallel := mustMinHash(t, cfg, sets)
+
+				if !reflect.DeepEqual(sequential.Signatures, parallel.Signatures) {
+					t.Fatal("Signatures differ from sequential build")
+				}
+				if !reflect.DeepEqual(sequential.Sizes, parallel.Sizes) {
+					t.Fatal("Sizes differ from sequential build")
+				}
+				if !reflect.DeepEqual(sequential.Buckets, parallel.Buckets) {
+					t.Fatal("Buckets differ from sequential build")
+				}
+			})
+		}
+	}
+}
+
+// TestParallelFor проверяет, что каждый номер обрабатывается ровно один раз
+func TestParallelFor(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 100} {
+		counts := make([]int, 57)
+		parallelFor(len(counts), workers, func(i int) { counts[i]++ })
+		for i, c := range counts {
+			if c != 1 {
+				t.Fatalf("workers=%d: index %d processed %d times", workers, i, c)
+			}
+		}
+	}
+}
+
+// BenchmarkParallelBuild измеряет масштабирование построения MinHash по числу горутин
+// на двух самых больших корпусах из data
+func BenchmarkParallelBuild(b *testing.B) {
+	files := []string{"data/articles_1000.text", "data/articles_2500.text"}
+	workers := []int{1, 2, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workers = append(workers, n)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			b.Fatalf("Нет корпуса %s: %v", file, err)
+		}
+		sets, _ := create_sets_from_file(file)
+		for _, w := range workers {
+			measureTime(b, fmt.Sprintf("%s/workers-%d", file, w), func() {
+				mustMinHash(b, Config{HashFunctions: 100, Bands: 10, Workers: w}, sets)
+			})
+		}
+	}
+}
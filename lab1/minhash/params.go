@@ -0,0 +1,88 @@
+package minhash
+
+import (
+	"fmt"
+	"math"
+)
+
+// integrationSteps – число отрезков (чётное) в формуле Симпсона при интегрировании S-кривой.
+const integrationSteps = 1000
+
+// CollisionProbability возвращает вероятность того, что два документа со сходством
+// Жаккара s попадут в общий бакет хотя бы одной из bands полос по rows строк:
+// 1 - (1 - s^r)^b.
+func CollisionProbability(s float64, bands, rows int) float64 {
+	return 1 - math.Pow(1-math.Pow(s, float64(rows)), float64(bands))
+}
+
+// CollisionProbability возвращает теоретическую вероятность того, что документ со
+// сходством s с запросом окажется среди кандидатов при текущем разбиении на полосы.
+func (mh *MinHash) CollisionProbability(s float64) float64 {
+	return CollisionProbability(s, mh.Bands, mh.Size/mh.Bands)
+}
+
+// integrate вычисляет интеграл f на отрезке [a, b] по формуле Симпсона.
+func integrate(f func(float64) float64, a, b float64) float64 {
+	return integrateSteps(f, a, b, integrationSteps)
+}
+
+// integrateSteps вычисляет интеграл по формуле Симпсона с заданным чётным числом отрезков.
+func integrateSteps(f func(float64) float64, a, b float64, steps int) float64 {
+	h := (b - a) / float64(steps)
+	sum := f(a) + f(b)
+	for i := 1; i < steps; i++ {
+		if i%2 == 1 {
+			sum += 4 * f(a+float64(i)*h)
+		} else {
+			sum += 2 * f(a+float64(i)*h)
+		}
+	}
+	return sum * h / 3
+}
+
+// falsePositiveProbability – площадь под S-кривой левее порога:
+// доля пар со сходством ниже threshold, которые всё же станут кандидатами.
+func falsePositiveProbability(threshold float64, bands, rows int) float64 {
+	return integrate(func(s float64) float64 {
+		return CollisionProbability(s, bands, rows)
+	}, 0, threshold)
+}
+
+// falseNegativeProbability – площадь над S-кривой правее порога:
+// доля пар со сходством выше threshold, которые не станут кандидатами.
+func falseNegativeProbability(threshold float64, bands, rows int) float64 {
+	return integrate(func(s float64) float64 {
+		return 1 - CollisionProbability(s, bands, rows)
+	}, threshold, 1)
+}
+
+// OptimalParams подбирает число полос и строк (b*r <= hash_func_cnt), минимизирующее
+// взвешенную сумму fpWeight*FP + fnWeight*FN ложноположительной и ложноотрицательной площадей
+// для порога сходства threshold.
+func OptimalParams(hash_func_cnt int, threshold, fpWeight, fnWeight float64) (bands, rows int) {
+	minError := math.Inf(1)
+	for b := 1; b <= hash_func_cnt; b++ {
+		for r := 1; b*r <= hash_func_cnt; r++ {
+			fp := falsePositiveProbability(threshold, b, r)
+			fn := falseNegativeProbability(threshold, b, r)
+			if err := fpWeight*fp + fnWeight*fn; err < minError {
+				minError = err
+				bands, rows = b, r
+			}
+		}
+	}
+	return bands, rows
+}
+
+// NewMinHashForThreshold строит MinHash, сам выбирая число полос и строк под порог
+// сходства threshold. cfg.HashFunctions задаёт бюджет хэш-функций, cfg.Bands не используется:
+// индекс получает ровно bands*rows хэш-функций (не больше бюджета),
+// поэтому ни одна строка сигнатуры не остаётся вне полос.
+func NewMinHashForThreshold(cfg Config, threshold, fpWeight, fnWeight float64, sets [][]string) (*MinHash, error) {
+	if cfg.HashFunctions < 1 {
+		return nil, fmt.Errorf("%w: need at least one hash function, got %d", ErrInvalidConfig, cfg.HashFunctions)
+	}
+	bands, rows := OptimalParams(cfg.HashFunctions, threshold, fpWeight, fnWeight)
+	cfg.HashFunctions, cfg.Bands = bands*rows, bands
+	return NewMinHash(cfg, sets)
+}
@@ -0,0 +1,70 @@
+package minhash
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCollisionProbability проверяет форму S-кривой
+func TestCollisionProbability(t *testing.T) {
+	if p := CollisionProbability(0, 20, 5); p != 0 {
+		t.Errorf("Expected 0 at s=0, got %v", p)
+	}
+	if p := CollisionProbability(1, 20, 5); p != 1 {
+		t.Errorf("Expected 1 at s=1, got %v", p)
+	}
+
+	prev := 0.0
+	for s := 0.05; s <= 1; s += 0.05 {
+		p := CollisionProbability(s, 20, 5)
+		if p < prev {
+			t.Fatalf("S-curve is not monotonic at s=%v", s)
+		}
+		prev = p
+	}
+
+	// Интеграл Симпсона точен для многочленов малой степени.
+	if got := falsePositiveProbability(1, 1, 2); math.Abs(got-1.0/3) > 1e-9 {
+		t.Errorf("Expected integral of s^2 to be 1/3, got %v", got)
+	}
+}
+
+// TestOptimalParams проверяет выбор числа полос и строк по порогу
+func TestOptimalParams(t *testing.T) {
+	for _, threshold := range []float64{0.3, 0.5, 0.8, 0.9} {
+		bands, rows := OptimalParams(128, threshold, 0.5, 0.5)
+		if bands*rows > 128 || bands < 1 || rows < 1 {
+			t.Fatalf("Threshold %v: invalid params b=%d r=%d", threshold, bands, rows)
+		}
+
+		// Точка перегиба S-кривой (1/b)^(1/r) должна быть рядом с порогом.
+		inflection := math.Pow(1/float64(bands), 1/float64(rows))
+		if math.Abs(inflection-threshold) > 0.15 {
+			t.Errorf("Threshold %v: b=%d r=%d, inflection %v", threshold, bands, rows, inflection)
+		}
+		t.Logf("Threshold %v: b=%d r=%d", threshold, bands, rows)
+	}
+
+	// Рост веса ложноотрицательных срабатываний не уменьшает вероятность коллизии на пороге.
+	b1, r1 := OptimalParams(128, 0.7, 0.9, 0.1)
+	b2, r2 := OptimalParams(128, 0.7, 0.1, 0.9)
+	if CollisionProbability(0.7, b1, r1) > CollisionProbability(0.7, b2, r2) {
+		t.Errorf("Expected higher recall with larger FN weight: (%d,%d) vs (%d,%d)", b1, r1, b2, r2)
+	}
+}
+
+// TestNewMinHashForThreshold проверяет конструктор с автоматическим выбором параметров
+func TestNewMinHashForThreshold(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+
+	mh, err := NewMinHashForThreshold(Config{HashFunctions: 100}, 0.8, 0.5, 0.5, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mh.Size%mh.Bands != 0 || mh.Size > 100 {
+		t.Fatalf("Unexpected params: size=%d bands=%d", mh.Size, mh.Bands)
+	}
+	if p := mh.CollisionProbability(0.95); p < 0.9 {
+		t.Errorf("Expected high collision probability for similar documents, got %v", p)
+	}
+}
@@ -0,0 +1,443 @@
+package minhash
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"lab1/hyperloglog"
+	"lab1/tokenizer"
+)
+
+// Формат файла: магическая строка, версия, параметры индекса (размер сигнатуры, полосы,
+// b-bit, режим одной перестановки, зерно, точность скетчей), коэффициенты перестановок,
+// настройки токенизатора, документы (идентификатор, размер, сигнатура, скетч), бакеты полос
+// и контрольная сумма CRC-32 всего предыдущего содержимого. Целые числа записываются как
+// varint, значения сигнатур и ключи бакетов – как 8 байт little-endian.
+const (
+	persistMagic   = "MNHS"
+	persistVersion = 1
+)
+
+var (
+	// ErrIncompatible – файл построен с другими параметрами, чем ожидает вызывающий.
+	ErrIncompatible = errors.New("incompatible MinHash file")
+	// ErrCorrupted – файл повреждён или имеет неизвестный формат.
+	ErrCorrupted = errors.New("corrupted MinHash file")
+)
+
+// Save записывает состояние индекса в w.
+func (mh *MinHash) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := &encoder{w: bw}
+
+	enc.bytes([]byte(persistMagic))
+	enc.uvarint(persistVersion)
+
+	enc.uvarint(uint64(mh.Size))
+	enc.uvarint(uint64(mh.Bands))
+	enc.uvarint(uint64(mh.BBits))
+	enc.bool(mh.OnePermutation)
+	enc.varint(mh.Seed)
+	enc.uvarint(uint64(mh.SketchPrecision))
+
+	enc.uvarint(uint64(len(mh.Permutations)))
+	for _, perm := range mh.Permutations {
+		enc.uint64(perm.a)
+		enc.uint64(perm.b)
+	}
+
+	enc.bool(mh.Tokenizer != nil)
+	if tok := mh.Tokenizer; tok != nil {
+		enc.uvarint(uint64(tok.Mode))
+		enc.uvarint(uint64(tok.K))
+		enc.bool(tok.FoldCase)
+		enc.bool(tok.StripPunctuation)
+		stopwords := make([]string, 0, len(tok.Stopwords))
+		for word, ok := range tok.Stopwords {
+			if ok {
+				stopwords = append(stopwords, word)
+			}
+		}
+		sort.Strings(stopwords)
+		enc.uvarint(uint64(len(stopwords)))
+		for _, word := range stopwords {
+			enc.string(word)
+		}
+	}
+
+	enc.uvarint(uint64(len(mh.Signatures)))
+	for slot, sig := range mh.Signatures {
+		enc.bool(sig != nil)
+		if sig == nil {
+			continue
+		}
+		enc.string(mh.IDs[slot])
+		enc.uvarint(uint64(mh.Sizes[slot]))
+		for _, v := range sig {
+			enc.uint64(v)
+		}
+		if mh.SketchPrecision != 0 {
+			data, err := mh.Sketches[slot].MarshalBinary()
+			if err != nil {
+				return err
+			}
+			enc.uvarint(uint64(len(data)))
+			enc.bytes(data)
+		}
+	}
+
+	for band := 0; band < mh.Bands; band++ {
+		bandBuckets := mh.Buckets[band]
+		keys := make([]uint64, 0, len(bandBuckets))
+		for key := range bandBuckets {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		enc.uvarint(uint64(len(keys)))
+		for _, key := range keys {
+			enc.uint64(key)
+			enc.uvarint(uint64(len(bandBuckets[key])))
+			for _, id := range bandBuckets[key] {
+				enc.uvarint(uint64(id))
+			}
+		}
+	}
+
+	if enc.err != nil {
+		return enc.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], enc.crc)
+	if _, err := bw.Write(sum[:]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// SaveFile атомарно записывает состояние индекса в файл path.
+func (mh *MinHash) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := mh.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load читает индекс, сохранённый Save. Файл должен быть построен с теми же HashFunctions,
+// Bands, BBits, OnePermutation, SketchPrecision и, если cfg.Seed не 0, тем же Seed, что в cfg;
+// при несовпадении возвращается ErrIncompatible. Если файл хранит токенизатор, непустой
+// cfg.Tokenizer должен совпадать с ним, иначе тоже возвращается ErrIncompatible; если не хранит,
+// используется cfg.Tokenizer. Workers применяется к загруженному индексу.
+// IDs не используется: идентификаторы берутся из файла.
+func Load(r io.Reader, cfg Config) (*MinHash, error) {
+	dec := &decoder{r: bufio.NewReader(r)}
+
+	if magic := dec.bytes(len(persistMagic)); dec.err != nil || string(magic) != persistMagic {
+		return nil, ErrCorrupted
+	}
+	version := dec.uvarint()
+	if dec.err == nil && version != persistVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorrupted, version)
+	}
+
+	mh := &MinHash{
+		Size:            int(dec.uvarint()),
+		Bands:           int(dec.uvarint()),
+		BBits:           int(dec.uvarint()),
+		OnePermutation:  dec.bool(),
+		Seed:            dec.varint(),
+		SketchPrecision: int(dec.uvarint()),
+		Buckets:         make(map[int]map[uint64][]int),
+		slots:           make(map[string]int),
+	}
+	if dec.err != nil {
+		return nil, dec.corrupted()
+	}
+
+	if mh.Size != cfg.HashFunctions || mh.Bands != cfg.Bands {
+		return nil, fmt.Errorf("%w: file has %d hash functions and %d bands, expected %d and %d",
+			ErrIncompatible, mh.Size, mh.Bands, cfg.HashFunctions, cfg.Bands)
+	}
+	if (cfg.Seed != 0 && cfg.Seed != mh.Seed) || cfg.BBits != mh.BBits || cfg.OnePermutation != mh.OnePermutation {
+		return nil, fmt.Errorf("%w: seed, b-bit or one-permutation settings differ", ErrIncompatible)
+	}
+	if cfg.SketchPrecision != mh.SketchPrecision {
+		return nil, fmt.Errorf("%w: file has sketch precision %d, expected %d",
+			ErrIncompatible, mh.SketchPrecision, cfg.SketchPrecision)
+	}
+	mh.Workers = cfg.Workers
+	mh.Tokenizer = cfg.Tokenizer
+	if mh.Size < 1 || mh.Bands < 1 || mh.Bands > mh.Size || !validBBits(mh.BBits) ||
+		(mh.SketchPrecision != 0 && (mh.SketchPrecision < hyperloglog.MinPrecision || mh.SketchPrecision > hyperloglog.MaxPrecision)) {
+		return nil, fmt.Errorf("%w: invalid parameters", ErrCorrupted)
+	}
+
+	expectedPermutations := mh.Size
+	if mh.OnePermutation {
+		expectedPermutations = 1
+	}
+	permutations := int(dec.uvarint())
+	if dec.err == nil && permutations != expectedPermutations {
+		return nil, fmt.Errorf("%w: unexpected number of permutations %d", ErrCorrupted, permutations)
+	}
+	mh.Permutations = make([]permutation, permutations)
+	for i := range mh.Permutations {
+		mh.Permutations[i] = permutation{a: dec.uint64(), b: dec.uint64()}
+	}
+
+	if dec.bool() {
+		tok := &tokenizer.Tokenizer{
+			Mode:             tokenizer.Mode(dec.uvarint()),
+			K:                int(dec.uvarint()),
+			FoldCase:         dec.bool(),
+			StripPunctuation: dec.bool(),
+		}
+		if count := dec.uvarint(); count > 0 && dec.err == nil {
+			tok.Stopwords = make(map[string]bool)
+			for i := uint64(0); i < count && dec.err == nil; i++ {
+				tok.Stopwords[dec.string()] = true
+			}
+		}
+		if cfg.Tokenizer != nil && dec.err == nil && !sameTokenizer(cfg.Tokenizer, tok) {
+			return nil, fmt.Errorf("%w: tokenizer settings differ from the stored ones", ErrIncompatible)
+		}
+		mh.Tokenizer = tok
+	}
+
+	sigLen := len(mh.compact(make([]uint64, mh.Size)))
+	docs := dec.uvarint()
+	for slot := uint64(0); slot < docs && dec.err == nil; slot++ {
+		if !dec.bool() {
+			mh.Signatures = append(mh.Signatures, nil)
+			mh.Sizes = append(mh.Sizes, 0)
+			mh.IDs = append(mh.IDs, "")
+			if mh.SketchPrecision != 0 {
+				mh.Sketches = append(mh.Sketches, nil)
+			}
+			continue
+		}
+		id := dec.string()
+		if id == "" && dec.err == nil {
+			return nil, fmt.Errorf("%w: empty document ID", ErrCorrupted)
+		}
+		if _, exists := mh.slots[id]; exists && dec.err == nil {
+			return nil, fmt.Errorf("%w: duplicate document ID %q", ErrCorrupted, id)
+		}
+		mh.slots[id] = int(slot)
+		mh.IDs = append(mh.IDs, id)
+		mh.Sizes = append(mh.Sizes, int(dec.uvarint()))
+		sig := make([]uint64, sigLen)
+		for i := range sig {
+			sig[i] = dec.uint64()
+		}
+		mh.Signatures = append(mh.Signatures, sig)
+		if mh.SketchPrecision != 0 {
+			sketch := &hyperloglog.Sketch{}
+			if n := dec.uvarint(); dec.err == nil {
+				if n > maxStringLen {
+					return nil, fmt.Errorf("%w: sketch of %d bytes", ErrCorrupted, n)
+				}
+				if err := sketch.UnmarshalBinary(dec.bytes(int(n))); dec.err == nil && err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+				}
+			}
+			mh.Sketches = append(mh.Sketches, sketch)
+		}
+	}
+
+	for band := 0; band < mh.Bands && dec.err == nil; band++ {
+		mh.Buckets[band] = make(map[uint64][]int)
+		keys := dec.uvarint()
+		for k := uint64(0); k < keys && dec.err == nil; k++ {
+			key := dec.uint64()
+			count := dec.uvarint()
+			var ids []int
+			for i := uint64(0); i < count && dec.err == nil; i++ {
+				id := dec.uvarint()
+				if id >= uint64(len(mh.Signatures)) || mh.Signatures[id] == nil {
+					return nil, fmt.Errorf("%w: bucket refers to missing document %d", ErrCorrupted, id)
+				}
+				ids = append(ids, int(id))
+			}
+			mh.Buckets[band][key] = ids
+		}
+	}
+	if dec.err != nil {
+		return nil, dec.corrupted()
+	}
+
+	sum := dec.crc
+	var stored [4]byte
+	if _, err := io.ReadFull(dec.r, stored[:]); err != nil || binary.LittleEndian.Uint32(stored[:]) != sum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupted)
+	}
+	return mh, nil
+}
+
+// sameTokenizer сообщает, нарезают ли a и b тексты одинаково.
+func sameTokenizer(a, b *tokenizer.Tokenizer) bool {
+	if a.Mode != b.Mode || max(a.K, 1) != max(b.K, 1) ||
+		a.FoldCase != b.FoldCase || a.StripPunctuation != b.StripPunctuation {
+		return false
+	}
+	for word, ok := range a.Stopwords {
+		if ok != b.Stopwords[word] {
+			return false
+		}
+	}
+	for word, ok := range b.Stopwords {
+		if ok != a.Stopwords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadFile читает индекс из файла path, см. Load.
+func LoadFile(path string, cfg Config) (*MinHash, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Load(file, cfg)
+}
+
+// encoder пишет значения и считает по ним контрольную сумму; первая ошибка сохраняется.
+type encoder struct {
+	w   io.Writer
+	crc uint32
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) bytes(b []byte) {
+	if e.err != nil {
+		return
+	}
+	e.crc = crc32.Update(e.crc, crc32.IEEETable, b)
+	_, e.err = e.w.Write(b)
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.bytes(e.buf[:binary.PutUvarint(e.buf[:], v)])
+}
+
+func (e *encoder) varint(v int64) {
+	e.bytes(e.buf[:binary.PutVarint(e.buf[:], v)])
+}
+
+func (e *encoder) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:8], v)
+	e.bytes(e.buf[:8])
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.bytes([]byte{1})
+	} else {
+		e.bytes([]byte{0})
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.bytes([]byte(s))
+}
+
+// decoder читает значения, записанные encoder, и считает контрольную сумму прочитанного.
+type decoder struct {
+	r   *bufio.Reader
+	crc uint32
+	err error
+}
+
+// ReadByte реализует io.ByteReader для binary.ReadUvarint.
+func (d *decoder) ReadByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, []byte{b})
+	}
+	return b, err
+}
+
+func (d *decoder) corrupted() error {
+	if errors.Is(d.err, ErrCorrupted) {
+		return d.err
+	}
+	return fmt.Errorf("%w: %v", ErrCorrupted, d.err)
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	if _, d.err = io.ReadFull(d.r, b); d.err != nil {
+		return nil
+	}
+	d.crc = crc32.Update(d.crc, crc32.IEEETable, b)
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var v uint64
+	v, d.err = binary.ReadUvarint(d)
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	var v int64
+	v, d.err = binary.ReadVarint(d)
+	return v
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) bool() bool {
+	b := d.bytes(1)
+	return b != nil && b[0] == 1
+}
+
+// maxStringLen ограничивает длину строки, чтобы повреждённый файл не вызвал огромную аллокацию.
+const maxStringLen = 1 << 20
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err == nil && n > maxStringLen {
+		d.err = fmt.Errorf("%w: string of %d bytes", ErrCorrupted, n)
+	}
+	return string(d.bytes(int(n)))
+}
@@ -0,0 +1,169 @@
+package minhash
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"lab1/tokenizer"
+)
+
+// TestSaveLoad проверяет, что загруженный индекс совпадает с сохранённым и сразу отвечает на запросы
+func TestSaveLoad(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_100.text")
+	ids := make([]string, len(sets))
+	for num, id := range id_to_num {
+		ids[num] = id
+	}
+
+	configs := []struct {
+		name string
+		cfg  Config
+	}{
+		{"full", Config{HashFunctions: 64, Bands: 16, Seed: 1, IDs: ids}},
+		{"b-bit", Config{HashFunctions: 64, Bands: 16, Seed: 2, BBits: 4}},
+		{"one-permutation", Config{HashFunctions: 64, Bands: 16, Seed: 3, OnePermutation: true}},
+		{"sketches", Config{HashFunctions: 64, Bands: 16, Seed: 4, SketchPrecision: 8}},
+	}
+
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			mh := mustMinHash(t, config.cfg, sets)
+			removed := mh.ID(10)
+			if err := mh.Remove(removed); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := mh.Save(&buf); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(&buf, config.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(mh.Signatures, loaded.Signatures) || !reflect.DeepEqual(mh.Sizes, loaded.Sizes) {
+				t.Fatal("Signatures differ after loading")
+			}
+			if !reflect.DeepEqual(mh.Sketches, loaded.Sketches) {
+				t.Fatal("Sketches differ after loading")
+			}
+			if !reflect.DeepEqual(mh.IDs, loaded.IDs) {
+				t.Fatal("IDs differ after loading")
+			}
+			if !reflect.DeepEqual(mh.Buckets, loaded.Buckets) {
+				t.Fatal("Buckets differ after loading")
+			}
+			if !reflect.DeepEqual(mh.Permutations, loaded.Permutations) {
+				t.Fatal("Permutations differ after loading")
+			}
+			if !reflect.DeepEqual(mh.QueryTopK(sets[5], 3), loaded.QueryTopK(sets[5], 3)) {
+				t.Error("Query results differ after loading")
+			}
+
+			if err := loaded.Add(mh.ID(5), sets[5]); err != ErrDocumentExists {
+				t.Errorf("Expected ErrDocumentExists, got %v", err)
+			}
+			if err := loaded.Add(removed, sets[10]); err != nil {
+				t.Fatal(err)
+			}
+			if result := loaded.QueryTopK(sets[10], 1); len(result) != 1 || result[0].ID != removed {
+				t.Errorf("Expected re-added document, got %v", result)
+			}
+		})
+	}
+}
+
+// TestSaveLoadFile проверяет сохранение в файл вместе с настройками токенизатора
+func TestSaveLoadFile(t *testing.T) {
+	tok := &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 2, FoldCase: true, Stopwords: tokenizer.NewStopwords("the")}
+	texts := []string{"the quick brown fox jumps", "a lazy dog sleeps all day"}
+	mh, err := NewMinHashFromTexts(Config{HashFunctions: 32, Bands: 8, Seed: 5, Tokenizer: tok}, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.mh")
+	if err := mh.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(path, Config{HashFunctions: 32, Bands: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mh.Tokenizer, loaded.Tokenizer) {
+		t.Errorf("Expected tokenizer %+v, got %+v", mh.Tokenizer, loaded.Tokenizer)
+	}
+	if result := loaded.QueryText("THE quick brown fox jumps", 1); len(result) != 1 || result[0].ID != "0" {
+		t.Errorf("Expected document 0, got %v", result)
+	}
+
+	same := &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 2, FoldCase: true, Stopwords: tokenizer.NewStopwords("the")}
+	if _, err := LoadFile(path, Config{HashFunctions: 32, Bands: 8, Tokenizer: same}); err != nil {
+		t.Errorf("Load with an equal tokenizer: %v", err)
+	}
+	other := &tokenizer.Tokenizer{Mode: tokenizer.Chars, K: 2, FoldCase: true, Stopwords: tokenizer.NewStopwords("the")}
+	if _, err := LoadFile(path, Config{HashFunctions: 32, Bands: 8, Tokenizer: other}); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Load with another tokenizer: expected ErrIncompatible, got %v", err)
+	}
+}
+
+// TestLoadIncompatible проверяет отказ загружать файл с другими параметрами
+func TestLoadIncompatible(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+	mh := mustMinHash(t, Config{HashFunctions: 64, Bands: 16, Seed: 1}, sets)
+
+	var buf bytes.Buffer
+	if err := mh.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"hash count", Config{HashFunctions: 128, Bands: 16}},
+		{"bands", Config{HashFunctions: 64, Bands: 8}},
+		{"seed", Config{HashFunctions: 64, Bands: 16, Seed: 2}},
+		{"b-bit", Config{HashFunctions: 64, Bands: 16, BBits: 8}},
+		{"one-permutation", Config{HashFunctions: 64, Bands: 16, OnePermutation: true}},
+		{"sketches", Config{HashFunctions: 64, Bands: 16, SketchPrecision: 10}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Load(bytes.NewReader(data), test.cfg); !errors.Is(err, ErrIncompatible) {
+				t.Errorf("Expected ErrIncompatible, got %v", err)
+			}
+		})
+	}
+
+	if _, err := Load(bytes.NewReader(data), Config{HashFunctions: 64, Bands: 16, Seed: 1}); err != nil {
+		t.Errorf("Expected matching seed to load, got %v", err)
+	}
+}
+
+// TestLoadCorrupted проверяет обнаружение повреждённых файлов
+func TestLoadCorrupted(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+	mh := mustMinHash(t, Config{HashFunctions: 16, Bands: 4, Seed: 1}, sets)
+
+	var buf bytes.Buffer
+	if err := mh.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)/2] ^= 0xff
+	truncated := data[:len(data)-10]
+
+	for name, input := range map[string][]byte{"flipped": flipped, "truncated": truncated, "garbage": []byte("hello")} {
+		if _, err := Load(bytes.NewReader(input), Config{HashFunctions: 16, Bands: 4}); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s: expected ErrCorrupted, got %v", name, err)
+		}
+	}
+}
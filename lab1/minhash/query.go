@@ -0,0 +1,86 @@
+package minhash
+
+import "sort"
+
+// QueryResult – документ-кандидат и оценка его сходства Жаккара с запросом.
+type QueryResult struct {
+	ID         string  `json:"id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Query ищет документы, похожие на set: хэширует полосы сигнатуры запроса,
+// собирает кандидатов из Buckets и оставляет тех, у кого оценка сходства
+// не меньше threshold. Результат отсортирован по убыванию сходства.
+func (mh *MinHash) Query(set []string, threshold float64) []QueryResult {
+	return filterByThreshold(mh.queryCandidates(mh.generateSignature(set), distinctCount(set)), threshold)
+}
+
+// filterByThreshold оставляет кандидатов со сходством не меньше threshold.
+func filterByThreshold(candidates []QueryResult, threshold float64) []QueryResult {
+	var result []QueryResult
+	for _, candidate := range candidates {
+		if candidate.Similarity >= threshold {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// QueryTopK возвращает не более k кандидатов с наибольшей оценкой сходства с set;
+// при k <= 0 – nil.
+func (mh *MinHash) QueryTopK(set []string, k int) []QueryResult {
+	return topK(mh.queryCandidates(mh.generateSignature(set), distinctCount(set)), k)
+}
+
+func topK(result []QueryResult, k int) []QueryResult {
+	if k <= 0 {
+		return nil
+	}
+	if k < len(result) {
+		result = result[:k]
+	}
+	return result
+}
+
+// queryCandidates возвращает всех кандидатов из бакетов для сигнатуры sig
+// множества из size элементов, ранжированных по оценке сходства.
+func (mh *MinHash) queryCandidates(sig []uint64, size int) []QueryResult {
+	if size == 0 {
+		return nil
+	}
+	sig = mh.compact(sig)
+	seen := make(map[int]bool)
+	var slots []int
+
+	for band := 0; band < mh.Bands; band++ {
+		for _, slot := range mh.Buckets[band][mh.bandKey(sig, band)] {
+			if !seen[slot] {
+				seen[slot] = true
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return mh.rank(sig, size, slots)
+}
+
+// rank оценивает сходство хранимой сигнатуры sig с документами в позициях slots
+// и сортирует их по убыванию сходства, а при равенстве – по порядку добавления.
+func (mh *MinHash) rank(sig []uint64, size int, slots []int) []QueryResult {
+	similarity := make(map[int]float64, len(slots))
+	for _, slot := range slots {
+		similarity[slot] = mh.compare(sig, mh.Signatures[slot], size, mh.Sizes[slot])
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		if similarity[slots[i]] != similarity[slots[j]] {
+			return similarity[slots[i]] > similarity[slots[j]]
+		}
+		return slots[i] < slots[j]
+	})
+
+	result := make([]QueryResult, len(slots))
+	for i, slot := range slots {
+		result[i] = QueryResult{ID: mh.IDs[slot], Similarity: similarity[slot]}
+	}
+	return result
+}
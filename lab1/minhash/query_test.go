@@ -0,0 +1,65 @@
+package minhash
+
+import "testing"
+
+// TestQuery проверяет поиск ближайших соседей для документа-запроса
+func TestQuery(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_100.text")
+	expected := create_expected_result_from_file("data/articles_100.test")
+
+	ids := make([]string, len(sets))
+	for num, id := range id_to_num {
+		ids[num] = id
+	}
+
+	mh := mustMinHash(t, Config{HashFunctions: 100, Bands: 20, IDs: ids}, sets)
+
+	for pair := range expected {
+		slot, _ := mh.Slot(pair[0])
+		query := sets[slot]
+		result := mh.Query(query, 0.8)
+
+		found := false
+		for i, rec := range result {
+			if i > 0 && result[i-1].Similarity < rec.Similarity {
+				t.Fatalf("Results are not sorted: %v", result)
+			}
+			if rec.Similarity < 0.8 {
+				t.Fatalf("Result %v below threshold", rec)
+			}
+			if rec.ID == pair[1] {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Query for %s: expected to find %s, got %v", pair[0], pair[1], result)
+		}
+	}
+}
+
+// TestQueryTopK проверяет ограничение числа результатов
+func TestQueryTopK(t *testing.T) {
+	sets, _ := create_sets_from_file("data/articles_100.text")
+	mh := mustMinHash(t, Config{HashFunctions: 100, Bands: 50}, sets)
+
+	result := mh.QueryTopK(sets[0], 1)
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(result))
+	}
+	if result[0].ID != "0" || result[0].Similarity != 1 {
+		t.Errorf("Expected document itself with similarity 1, got %v", result[0])
+	}
+
+	if got := mh.QueryTopK([]string{"qwertyuiop"}, 5); len(got) > 5 {
+		t.Errorf("Expected at most 5 results, got %d", len(got))
+	}
+
+	for _, k := range []int{0, -1} {
+		if got := mh.QueryTopK(sets[0], k); got != nil {
+			t.Errorf("QueryTopK with k=%d: expected nil, got %v", k, got)
+		}
+		if got := mh.QueryTextTopK("any text", k); got != nil {
+			t.Errorf("QueryTextTopK with k=%d: expected nil, got %v", k, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package minhash
+
+import "strings"
+
+// NewMinHashFromTexts строит индекс по текстам: каждый текст нарезается cfg.Tokenizer
+// на шинглы, хэши которых и образуют множество документа. Токенизатор сохраняется
+// для AddText и QueryText.
+func NewMinHashFromTexts(cfg Config, texts []string) (*MinHash, error) {
+	obj, err := newMinHash(cfg, len(texts))
+	if err != nil {
+		return nil, err
+	}
+
+	parallelFor(len(texts), obj.Workers, func(set_id int) {
+		sig, size := obj.textSignature(texts[set_id])
+		obj.Signatures[set_id] = obj.compact(sig)
+		obj.Sizes[set_id] = size
+		if obj.Sketches != nil {
+			obj.Sketches[set_id] = obj.textSketch(texts[set_id])
+		}
+	})
+
+	obj.bucketizeSignatures()
+
+	return obj, nil
+}
+
+// textSignature строит сигнатуру текста и возвращает число его различных шинглов.
+// Без Tokenizer текст делится на слова по пробелам, как в NewMinHash.
+func (mh *MinHash) textSignature(text string) ([]uint64, int) {
+	if mh.Tokenizer == nil {
+		words := strings.Fields(text)
+		return mh.generateSignature(words), distinctCount(words)
+	}
+	shingles := mh.Tokenizer.HashedShingles(text)
+	return mh.generateHashedSignature(shingles), len(shingles)
+}
+
+// AddText добавляет в индекс документ id, заданный текстом.
+func (mh *MinHash) AddText(id string, text string) error {
+	if err := mh.checkNewID(id); err != nil {
+		return err
+	}
+	sig, size := mh.textSignature(text)
+	mh.addSignature(id, sig, size, mh.textSketch(text))
+	return nil
+}
+
+// QueryText ищет документы, похожие на текст, со сходством не меньше threshold.
+func (mh *MinHash) QueryText(text string, threshold float64) []QueryResult {
+	return filterByThreshold(mh.queryCandidates(mh.textSignature(text)), threshold)
+}
+
+// QueryTextTopK возвращает не более k документов, наиболее похожих на текст.
+func (mh *MinHash) QueryTextTopK(text string, k int) []QueryResult {
+	return topK(mh.queryCandidates(mh.textSignature(text)), k)
+}
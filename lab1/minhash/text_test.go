@@ -0,0 +1,128 @@
+package minhash
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"lab1/tokenizer"
+)
+
+// TestTextQuery проверяет поиск по тексту с нормализацией
+func TestTextQuery(t *testing.T) {
+	tok := &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 2, FoldCase: true, StripPunctuation: true}
+	texts := []string{
+		"George W. Bush expressed confidence on Monday about passing an immigration bill",
+		"French President Nicolas Sarkozy announced Tuesday that he would visit China",
+	}
+	mh, err := NewMinHashFromTexts(Config{HashFunctions: 64, Bands: 32, Seed: 1, Tokenizer: tok}, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := mh.QueryText("GEORGE W BUSH expressed confidence, on Monday, about passing an immigration bill!", 0.9)
+	if len(result) != 1 || result[0].ID != "0" || result[0].Similarity != 1 {
+		t.Fatalf("Expected exact match with document 0, got %v", result)
+	}
+
+	if err := mh.AddText("2", "Nicolas Sarkozy announced on Tuesday that he would visit China"); err != nil {
+		t.Fatal(err)
+	}
+	if result := mh.QueryTextTopK(texts[1], 2); len(result) != 2 || result[0].ID != "1" || result[1].ID != "2" {
+		t.Errorf("Expected documents 1 and 2, got %v", result)
+	}
+}
+
+// TestShinglingRecall сравнивает полноту поиска дубликатов из articles_*.test
+// при разных способах нарезки текста на шинглы
+func TestShinglingRecall(t *testing.T) {
+	const threshold = 0.8
+
+	configs := []struct {
+		name string
+		tok  *tokenizer.Tokenizer
+	}{
+		{"fields", nil},
+		{"words-1", &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 1, FoldCase: true, StripPunctuation: true}},
+		{"words-3", &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 3, FoldCase: true, StripPunctuation: true}},
+		{"words-3-stopwords", &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 3, FoldCase: true, StripPunctuation: true,
+			Stopwords: tokenizer.NewStopwords(tokenizer.EnglishStopwords...)}},
+		{"chars-5", &tokenizer.Tokenizer{Mode: tokenizer.Chars, K: 5, FoldCase: true, StripPunctuation: true}},
+	}
+	files := [][3]string{
+		{"100", "data/articles_100.text", "data/articles_100.test"},
+		{"1000", "data/articles_1000.text", "data/articles_1000.test"},
+	}
+
+	for _, data_set := range files {
+		texts, id_to_num := create_texts_from_file(data_set[1])
+		expected := create_expected_result_from_file(data_set[2])
+
+		for _, config := range configs {
+			t.Run(fmt.Sprintf("%s/%s", data_set[0], config.name), func(t *testing.T) {
+				var mh *MinHash
+				if config.tok == nil {
+					sets := make([][]string, len(texts))
+					for i, text := range texts {
+						sets[i] = strings.Fields(text)
+					}
+					mh = mustMinHash(t, Config{HashFunctions: 100, Bands: 20, Seed: 1}, sets)
+				} else {
+					var err error
+					mh, err = NewMinHashFromTexts(Config{HashFunctions: 100, Bands: 20, Seed: 1, Tokenizer: config.tok}, texts)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				found := 0
+				reported := 0
+				for _, rec := range mh.Similarity() {
+					if rec[2] < threshold {
+						continue
+					}
+					reported++
+					if expected[[2]string{id_to_num[int(rec[0])], id_to_num[int(rec[1])]}] != 0 {
+						found++
+					}
+				}
+
+				recall := float64(found) / float64(len(expected))
+				t.Logf("%s: recall=%.2f (%d/%d), reported pairs=%d", config.name, recall, found, len(expected), reported)
+				if recall < 0.8 {
+					t.Errorf("Recall %.2f is too low", recall)
+				}
+			})
+		}
+	}
+}
+
+// create_texts_from_file читает файл, где каждая строка содержит идентификатор и текст,
+// и возвращает тексты и мапу соответствия индекса идентификатору.
+func create_texts_from_file(filepath string) ([]string, map[int]string) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	id_to_num := map[int]string{}
+	texts := []string{}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	i := 0
+	for scanner.Scan() {
+		id, text, _ := strings.Cut(scanner.Text(), " ")
+		id_to_num[i] = id
+		texts = append(texts, text)
+		i++
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return texts, id_to_num
+}
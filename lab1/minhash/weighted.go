@@ -0,0 +1,185 @@
+package minhash
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// WeightedMinHash строит сигнатуры документов с весами элементов (например, TF)
+// методом согласованной взвешенной выборки Иоффе (ICWS). Доля совпавших позиций
+// двух сигнатур оценивает взвешенный коэффициент Жаккара
+// sum(min(a_i, b_i)) / sum(max(a_i, b_i)).
+// Сигнатуры раскладываются по полосам в ту же структуру Buckets, что и у MinHash.
+type WeightedMinHash struct {
+	Size       int
+	Bands      int
+	Seed       int64
+	Signatures [][]uint64
+	Buckets    map[int]map[uint64][]int
+	// seeds – независимые зёрна для каждой из Size хэш-функций.
+	seeds []uint64
+	// empty отмечает документы без элементов с положительным весом.
+	empty []bool
+}
+
+// NewWeightedMinHash строит индекс по взвешенным документам с параметрами cfg.
+// Используются HashFunctions, Bands и Seed (0 – случайное зерно, выбранное сохраняется
+// в WeightedMinHash.Seed); они проверяются так же, как в NewMinHash. Остальные поля
+// к взвешенным сигнатурам не применимы и должны быть нулевыми. Ошибки оборачивают ErrInvalidConfig.
+func NewWeightedMinHash(cfg Config, docs []map[string]float64) (*WeightedMinHash, error) {
+	if err := cfg.validate(len(docs)); err != nil {
+		return nil, err
+	}
+	if cfg.BBits != 0 || cfg.OnePermutation || cfg.Workers != 0 || cfg.IDs != nil ||
+		cfg.SketchPrecision != 0 || cfg.Tokenizer != nil {
+		return nil, fmt.Errorf("%w: weighted MinHash supports only HashFunctions, Bands and Seed", ErrInvalidConfig)
+	}
+	obj := &WeightedMinHash{
+		Size:       cfg.HashFunctions,
+		Bands:      cfg.Bands,
+		Seed:       cfg.Seed,
+		Signatures: make([][]uint64, len(docs)),
+		Buckets:    make(map[int]map[uint64][]int),
+		seeds:      make([]uint64, cfg.HashFunctions),
+		empty:      make([]bool, len(docs)),
+	}
+	for obj.Seed == 0 {
+		obj.Seed = rand.Int63()
+	}
+
+	rng := rand.New(rand.NewSource(obj.Seed))
+	for i := range obj.seeds {
+		obj.seeds[i] = rng.Uint64()
+	}
+
+	// Пустые документы не попадают в бакеты, как и в MinHash: иначе их одинаковые
+	// сигнатуры из MaxUint64 совпадали бы во всех полосах.
+	signatures := make([][]uint64, len(docs))
+	for doc_id, weights := range docs {
+		obj.Signatures[doc_id] = obj.generateSignature(weights)
+		obj.empty[doc_id] = !hasPositiveWeight(weights)
+		if !obj.empty[doc_id] {
+			signatures[doc_id] = obj.Signatures[doc_id]
+		}
+	}
+
+	rows := obj.Size / obj.Bands
+	bucketize(obj.Buckets, signatures, obj.Bands, 1, func(sig []uint64, band int) uint64 {
+		return bandKey(sig, band, rows)
+	})
+
+	return obj, nil
+}
+
+// hasPositiveWeight сообщает, есть ли в документе элемент с положительным весом.
+func hasPositiveWeight(weights map[string]float64) bool {
+	for _, w := range weights {
+		if w > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitmix64 – генератор SplitMix64; из одного состояния получается поток независимых значений.
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// uniform возвращает число из (0, 1) по следующему значению потока.
+func uniform(state *uint64) float64 {
+	return (float64(splitmix64(state)>>11) + 0.5) / (1 << 53)
+}
+
+// generateSignature строит ICWS-сигнатуру. Для каждой хэш-функции случайные величины
+// r, c ~ Gamma(2, 1) и beta ~ U(0, 1) зависят только от элемента и номера функции,
+// поэтому одинаковые элементы в разных документах получают одинаковые выборки.
+// Элементы с неположительным весом не учитываются; пустой документ даёт сигнатуру из MaxUint64.
+func (wmh *WeightedMinHash) generateSignature(weights map[string]float64) []uint64 {
+	signature := make([]uint64, wmh.Size)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	for i, seed := range wmh.seeds {
+		minA := math.Inf(1)
+		for elem, w := range weights {
+			if w <= 0 {
+				continue
+			}
+			elemHash := hash(elem)
+			state := seed ^ elemHash
+			r := -math.Log(uniform(&state)) - math.Log(uniform(&state))
+			c := -math.Log(uniform(&state)) - math.Log(uniform(&state))
+			beta := uniform(&state)
+
+			t := math.Floor(math.Log(w)/r + beta)
+			y := math.Exp(r * (t - beta))
+			a := c / (y * math.Exp(r))
+			if a < minA {
+				minA = a
+				// Значение позиции – пара (элемент, t), сведённая к 64 битам.
+				sample := elemHash ^ uint64(int64(t))*0x9e3779b97f4a7c15
+				signature[i] = splitmix64(&sample)
+			}
+		}
+	}
+	return signature
+}
+
+// FindSimilarPairs возвращает пары документов, совпавших хотя бы в одной полосе.
+func (wmh *WeightedMinHash) FindSimilarPairs() [][]int {
+	return findSimilarPairs(wmh.Buckets)
+}
+
+// Similarity возвращает для пар-кандидатов оценку взвешенного сходства Жаккара.
+func (wmh *WeightedMinHash) Similarity() [][]float64 {
+	var result [][]float64
+	for _, pair := range wmh.FindSimilarPairs() {
+		similarity := estimateSimilarity(wmh.Signatures[pair[0]], wmh.Signatures[pair[1]])
+		result = append(result, []float64{float64(pair[0]), float64(pair[1]), similarity})
+	}
+	return result
+}
+
+// EstimateSimilarity оценивает взвешенное сходство Жаккара документов i и j по сигнатурам.
+// Для пустого документа сходство равно 0, как в WeightedJaccard.
+func (wmh *WeightedMinHash) EstimateSimilarity(i, j int) float64 {
+	if wmh.empty[i] || wmh.empty[j] {
+		return 0
+	}
+	return estimateSimilarity(wmh.Signatures[i], wmh.Signatures[j])
+}
+
+// WeightedJaccard вычисляет точный взвешенный коэффициент Жаккара.
+func WeightedJaccard(a, b map[string]float64) float64 {
+	var minSum, maxSum float64
+	for elem, wa := range a {
+		wb := b[elem]
+		minSum += math.Max(math.Min(wa, wb), 0)
+		maxSum += math.Max(math.Max(wa, wb), 0)
+	}
+	for elem, wb := range b {
+		if _, exists := a[elem]; !exists {
+			maxSum += math.Max(wb, 0)
+		}
+	}
+	if maxSum == 0 {
+		return 0
+	}
+	return minSum / maxSum
+}
+
+// TermFrequencies возвращает частоты слов документа для NewWeightedMinHash.
+func TermFrequencies(words []string) map[string]float64 {
+	tf := make(map[string]float64, len(words))
+	for _, word := range words {
+		tf[word]++
+	}
+	return tf
+}
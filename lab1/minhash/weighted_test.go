@@ -0,0 +1,136 @@
+package minhash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestWeightedJaccard проверяет точный взвешенный коэффициент Жаккара
+func TestWeightedJaccard(t *testing.T) {
+	a := map[string]float64{"x": 2, "y": 1}
+	b := map[string]float64{"x": 1, "z": 1}
+
+	// min: x=1; max: x=2, y=1, z=1
+	if got := WeightedJaccard(a, b); math.Abs(got-0.25) > 1e-12 {
+		t.Errorf("Expected 0.25, got %v", got)
+	}
+	if got := WeightedJaccard(a, a); got != 1 {
+		t.Errorf("Expected 1 for identical documents, got %v", got)
+	}
+	if got := WeightedJaccard(nil, nil); got != 0 {
+		t.Errorf("Expected 0 for empty documents, got %v", got)
+	}
+}
+
+// TestWeightedEstimate проверяет несмещённость оценки взвешенного сходства
+func TestWeightedEstimate(t *testing.T) {
+	const (
+		hashes = 64
+		trials = 100
+	)
+
+	a := map[string]float64{}
+	b := map[string]float64{}
+	for i := 0; i < 30; i++ {
+		a[fmt.Sprintf("w%d", i)] = float64(i%5 + 1)
+		b[fmt.Sprintf("w%d", i+10)] = float64(i%3+1) * 1.5
+	}
+	jaccard := WeightedJaccard(a, b)
+
+	var total float64
+	for seed := 1; seed <= trials; seed++ {
+		cfg := Config{HashFunctions: hashes, Bands: 8, Seed: int64(seed)}
+		wmh, err := NewWeightedMinHash(cfg, []map[string]float64{a, b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += wmh.EstimateSimilarity(0, 1)
+	}
+	mean := total / trials
+
+	stdErr := math.Sqrt(jaccard * (1 - jaccard) / (hashes * trials))
+	if math.Abs(mean-jaccard) > 4*stdErr {
+		t.Errorf("Expected mean %.4f, got %.4f (std err %.4f)", jaccard, mean, stdErr)
+	}
+	t.Logf("Weighted jaccard=%.4f mean estimate=%.4f", jaccard, mean)
+}
+
+// TestWeightedMinHashArticles проверяет поиск дубликатов по частотам слов
+func TestWeightedMinHashArticles(t *testing.T) {
+	sets, id_to_num := create_sets_from_file("data/articles_100.text")
+	expected := create_expected_result_from_file("data/articles_100.test")
+
+	docs := make([]map[string]float64, len(sets))
+	for i, set := range sets {
+		docs[i] = TermFrequencies(set)
+	}
+
+	wmh, err := NewWeightedMinHash(Config{HashFunctions: 100, Bands: 20, Seed: 1}, docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, rec := range wmh.Similarity() {
+		pair := [2]string{id_to_num[int(rec[0])], id_to_num[int(rec[1])]}
+		if expected[pair] != 0 {
+			found++
+			if rec[2] < 0.8 {
+				t.Errorf("Pair %v: similarity %v, expected close to 1", pair, rec[2])
+			}
+		} else if rec[2] > 0.95 {
+			t.Errorf("Pair %v: similarity %v, expected меньше", pair, rec[2])
+		}
+	}
+	if found != len(expected) {
+		t.Errorf("Expected %d duplicate pairs, found %d", len(expected), found)
+	}
+}
+
+// TestWeightedMinHashInvalid проверяет, что недопустимые параметры дают ошибку, а не панику
+func TestWeightedMinHashInvalid(t *testing.T) {
+	docs := []map[string]float64{{"a": 1}}
+	for _, params := range [][2]int{{16, 0}, {100, 30}, {-4, 2}, {0, 0}, {8, 16}} {
+		cfg := Config{HashFunctions: params[0], Bands: params[1], Seed: 1}
+		if _, err := NewWeightedMinHash(cfg, docs); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("NewWeightedMinHash(%d, %d): expected ErrInvalidConfig, got %v", params[0], params[1], err)
+		}
+	}
+	for _, cfg := range []Config{
+		{HashFunctions: 16, Bands: 4, BBits: 2},
+		{HashFunctions: 16, Bands: 4, OnePermutation: true},
+		{HashFunctions: 16, Bands: 4, IDs: []string{"a"}},
+	} {
+		if _, err := NewWeightedMinHash(cfg, docs); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("NewWeightedMinHash(%+v): expected ErrInvalidConfig, got %v", cfg, err)
+		}
+	}
+
+	wmh, err := NewWeightedMinHash(Config{HashFunctions: 16, Bands: 4}, docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wmh.Seed == 0 {
+		t.Error("Seed 0 should be replaced by a random seed")
+	}
+}
+
+// TestWeightedMinHashEmpty проверяет, что пустые документы и документы без положительных
+// весов не считаются похожими ни друг на друга, ни на остальные
+func TestWeightedMinHashEmpty(t *testing.T) {
+	docs := []map[string]float64{{}, {"a": 0, "b": -1}, {"a": 1, "b": 2}, {"a": 1, "b": 2}}
+	wmh, err := NewWeightedMinHash(Config{HashFunctions: 16, Bands: 4, Seed: 1}, docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairs := wmh.FindSimilarPairs(); len(pairs) != 1 || pairs[0][0] != 2 || pairs[0][1] != 3 {
+		t.Errorf("Expected only pair (2, 3), got %v", pairs)
+	}
+	if s := wmh.EstimateSimilarity(0, 1); s != 0 {
+		t.Errorf("Similarity of two empty documents = %v, want 0", s)
+	}
+	if s := wmh.EstimateSimilarity(0, 2); s != 0 {
+		t.Errorf("Similarity of empty and non-empty documents = %v, want 0", s)
+	}
+}
@@ -0,0 +1,166 @@
+package simhash
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+)
+
+var (
+	ErrInvalidDistance = errors.New("hamming distance must be in [0, 63]")
+	ErrDocumentExists  = errors.New("document already exists")
+)
+
+// Index находит все отпечатки в пределах расстояния Хэмминга K методом переставленных таблиц
+// (Manku, Jain, Das Sarma, 2007). 64 бита делятся на K+1 блоков: по принципу Дирихле
+// у отпечатков на расстоянии не больше K хотя бы один блок совпадает целиком.
+// Таблица t хранит отпечатки, циклически сдвинутые так, чтобы блок t стал старшими битами,
+// в отсортированном виде, и кандидаты с тем же блоком находятся двоичным поиском.
+type Index struct {
+	K int
+	// IDs и Fingerprints – идентификаторы и отпечатки документов в порядке добавления.
+	IDs          []string
+	Fingerprints []uint64
+	tables       []table
+	slots        map[string]int
+}
+
+type table struct {
+	// shift – номер первого бита блока, считая от старшего; width – ширина блока.
+	shift, width int
+	entries      []entry
+}
+
+type entry struct {
+	key  uint64
+	slot int
+}
+
+// Match – найденный документ и его расстояние Хэмминга до запроса.
+type Match struct {
+	ID       string
+	Distance int
+}
+
+// Pair – пара документов на расстоянии не больше K.
+type Pair struct {
+	A, B     string
+	Distance int
+}
+
+// NewIndex создаёт пустой индекс для поиска в пределах расстояния k.
+func NewIndex(k int) (*Index, error) {
+	if k < 0 || k >= Size {
+		return nil, ErrInvalidDistance
+	}
+	idx := &Index{K: k, slots: make(map[string]int)}
+	blocks := k + 1
+	shift := 0
+	for t := 0; t < blocks; t++ {
+		width := Size / blocks
+		if t < Size%blocks {
+			width++
+		}
+		idx.tables = append(idx.tables, table{shift: shift, width: width})
+		shift += width
+	}
+	return idx, nil
+}
+
+// permute переносит блок таблицы в старшие биты.
+func (t *table) permute(fp uint64) uint64 {
+	return bits.RotateLeft64(fp, t.shift)
+}
+
+// prefixRange возвращает границы ключей с тем же блоком, что у ключа key.
+func (t *table) prefixRange(key uint64) (lo, hi uint64) {
+	low := uint(Size - t.width)
+	lo = key >> low << low
+	return lo, lo | (1<<low - 1)
+}
+
+// Add добавляет документ id с отпечатком fp.
+func (idx *Index) Add(id string, fp uint64) error {
+	if _, exists := idx.slots[id]; exists {
+		return ErrDocumentExists
+	}
+	slot := len(idx.IDs)
+	idx.slots[id] = slot
+	idx.IDs = append(idx.IDs, id)
+	idx.Fingerprints = append(idx.Fingerprints, fp)
+
+	for i := range idx.tables {
+		t := &idx.tables[i]
+		key := t.permute(fp)
+		pos := sort.Search(len(t.entries), func(j int) bool { return t.entries[j].key > key })
+		t.entries = append(t.entries, entry{})
+		copy(t.entries[pos+1:], t.entries[pos:])
+		t.entries[pos] = entry{key: key, slot: slot}
+	}
+	return nil
+}
+
+// Len возвращает число документов в индексе.
+func (idx *Index) Len() int {
+	return len(idx.IDs)
+}
+
+// Query возвращает документы на расстоянии не больше K от fp,
+// отсортированные по расстоянию, а при равенстве – по порядку добавления.
+func (idx *Index) Query(fp uint64) []Match {
+	slots := idx.candidates(fp)
+	sort.Slice(slots, func(i, j int) bool {
+		di, dj := Distance(fp, idx.Fingerprints[slots[i]]), Distance(fp, idx.Fingerprints[slots[j]])
+		if di != dj {
+			return di < dj
+		}
+		return slots[i] < slots[j]
+	})
+
+	result := make([]Match, len(slots))
+	for i, slot := range slots {
+		result[i] = Match{ID: idx.IDs[slot], Distance: Distance(fp, idx.Fingerprints[slot])}
+	}
+	return result
+}
+
+// candidates возвращает позиции документов на расстоянии не больше K от fp без повторов.
+func (idx *Index) candidates(fp uint64) []int {
+	seen := make(map[int]bool)
+	var slots []int
+	for i := range idx.tables {
+		t := &idx.tables[i]
+		lo, hi := t.prefixRange(t.permute(fp))
+		start := sort.Search(len(t.entries), func(j int) bool { return t.entries[j].key >= lo })
+		for _, e := range t.entries[start:] {
+			if e.key > hi {
+				break
+			}
+			if seen[e.slot] {
+				continue
+			}
+			seen[e.slot] = true
+			if Distance(fp, idx.Fingerprints[e.slot]) <= idx.K {
+				slots = append(slots, e.slot)
+			}
+		}
+	}
+	return slots
+}
+
+// Pairs возвращает все пары документов на расстоянии не больше K
+// в порядке добавления первого, затем второго документа.
+func (idx *Index) Pairs() []Pair {
+	var pairs []Pair
+	for slot, fp := range idx.Fingerprints {
+		partners := idx.candidates(fp)
+		sort.Ints(partners)
+		for _, other := range partners {
+			if other <= slot {
+				continue
+			}
+			pairs = append(pairs, Pair{A: idx.IDs[slot], B: idx.IDs[other], Distance: Distance(fp, idx.Fingerprints[other])})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,88 @@
+package simhash
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestIndexQuery сверяет поиск по переставленным таблицам с полным перебором
+func TestIndexQuery(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	base := make([]uint64, 200)
+	for i := range base {
+		base[i] = rng.Uint64()
+	}
+	// Отпечатки-соседи: случайный базовый отпечаток с несколькими инвертированными битами.
+	var fps []uint64
+	for i := 0; i < 2000; i++ {
+		fp := base[rng.Intn(len(base))]
+		for flips := rng.Intn(10); flips > 0; flips-- {
+			fp ^= 1 << rng.Intn(Size)
+		}
+		fps = append(fps, fp)
+	}
+
+	for _, k := range []int{0, 3, 7, 12} {
+		t.Run(fmt.Sprintf("k=%d", k), func(t *testing.T) {
+			idx, err := NewIndex(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, fp := range fps {
+				if err := idx.Add(fmt.Sprint(i), fp); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var expectedPairs []Pair
+			for i := range fps {
+				for j := i + 1; j < len(fps); j++ {
+					if d := Distance(fps[i], fps[j]); d <= k {
+						expectedPairs = append(expectedPairs, Pair{A: fmt.Sprint(i), B: fmt.Sprint(j), Distance: d})
+					}
+				}
+			}
+			if pairs := idx.Pairs(); !reflect.DeepEqual(pairs, expectedPairs) {
+				t.Fatalf("Expected %d pairs, got %d", len(expectedPairs), len(pairs))
+			}
+
+			query := fps[0] ^ 1
+			expected := 0
+			for _, fp := range fps {
+				if Distance(query, fp) <= k {
+					expected++
+				}
+			}
+			result := idx.Query(query)
+			if len(result) != expected {
+				t.Fatalf("Expected %d matches, got %d", expected, len(result))
+			}
+			for i := 1; i < len(result); i++ {
+				if result[i-1].Distance > result[i].Distance {
+					t.Fatalf("Matches are not sorted: %v", result)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexErrors(t *testing.T) {
+	for _, k := range []int{-1, 64} {
+		if _, err := NewIndex(k); err != ErrInvalidDistance {
+			t.Errorf("k=%d: expected ErrInvalidDistance, got %v", k, err)
+		}
+	}
+
+	idx, _ := NewIndex(63)
+	if err := idx.Add("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Add("a", 2); err != ErrDocumentExists {
+		t.Errorf("Expected ErrDocumentExists, got %v", err)
+	}
+	if got := idx.Query(^uint64(0)); len(got) != 1 || got[0] != (Match{ID: "a", Distance: 63}) {
+		t.Errorf("Expected match a at distance 63, got %v", got)
+	}
+}
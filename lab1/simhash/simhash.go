@@ -0,0 +1,60 @@
+package simhash
+
+import (
+	"math/bits"
+
+	"lab1/internal/murmur"
+	"lab1/tokenizer"
+)
+
+// Size – длина отпечатка SimHash в битах.
+const Size = 64
+
+// Fingerprint строит 64-битный SimHash по весам признаков: каждый признак хэшируется,
+// и его вес прибавляется к счётчикам единичных бит хэша и вычитается из счётчиков нулевых.
+// Бит отпечатка равен 1, если итоговый счётчик положителен.
+func Fingerprint(weights map[string]float64) uint64 {
+	var counters [Size]float64
+	for feature, weight := range weights {
+		h := murmur.Mix(tokenizer.Hash(feature))
+		for bit := 0; bit < Size; bit++ {
+			if h&(1<<bit) != 0 {
+				counters[bit] += weight
+			} else {
+				counters[bit] -= weight
+			}
+		}
+	}
+
+	var fp uint64
+	for bit, counter := range counters {
+		if counter > 0 {
+			fp |= 1 << bit
+		}
+	}
+	return fp
+}
+
+// FromTokens строит SimHash по последовательности токенов, взвешенных частотой.
+func FromTokens(tokens []string) uint64 {
+	weights := make(map[string]float64, len(tokens))
+	for _, token := range tokens {
+		weights[token]++
+	}
+	return Fingerprint(weights)
+}
+
+// FromText нарезает текст tok на шинглы и строит SimHash с весами-частотами шинглов.
+func FromText(text string, tok *tokenizer.Tokenizer) uint64 {
+	return FromTokens(tok.Shingles(text))
+}
+
+// Distance возвращает расстояние Хэмминга между отпечатками.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similarity переводит расстояние Хэмминга в долю совпавших бит.
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(Distance(a, b))/Size
+}
@@ -0,0 +1,39 @@
+package simhash
+
+import (
+	"strings"
+	"testing"
+
+	"lab1/tokenizer"
+)
+
+func TestFingerprint(t *testing.T) {
+	tok := &tokenizer.Tokenizer{Mode: tokenizer.Words, K: 1, FoldCase: true, StripPunctuation: true}
+	text := "Nicolas Sarkozy announced on Tuesday that he would visit China next month " +
+		"to discuss trade relations and the situation in Tibet with the Chinese leadership"
+	edited := strings.Replace(text, "next month", "in April", 1)
+	other := "The stock market fell sharply on Monday as investors worried about rising oil prices and inflation"
+
+	original := FromText(text, tok)
+	if got := FromText(strings.ToUpper(text), tok); got != original {
+		t.Errorf("Expected case-insensitive fingerprint, distance %d", Distance(original, got))
+	}
+
+	near, far := Distance(original, FromText(edited, tok)), Distance(original, FromText(other, tok))
+	if near >= far {
+		t.Errorf("Expected edited text closer than unrelated: %d >= %d", near, far)
+	}
+	t.Logf("Edited: %d bits, unrelated: %d bits", near, far)
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(0, ^uint64(0)); d != 64 {
+		t.Errorf("Expected distance 64, got %d", d)
+	}
+	if s := Similarity(0b1011, 0b0010); s != 1-2.0/64 {
+		t.Errorf("Expected similarity %v, got %v", 1-2.0/64, s)
+	}
+	if Fingerprint(nil) != 0 {
+		t.Error("Expected zero fingerprint for empty document")
+	}
+}
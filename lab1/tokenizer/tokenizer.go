@@ -0,0 +1,141 @@
+package tokenizer
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Mode – способ нарезки текста на шинглы.
+type Mode int
+
+const (
+	// Words – шинглы из K подряд идущих слов.
+	Words Mode = iota
+	// Chars – шинглы из K подряд идущих символов нормализованного текста.
+	Chars
+)
+
+// Tokenizer нормализует текст и нарезает его на шинглы.
+type Tokenizer struct {
+	Mode Mode
+	// K – длина шингла в словах или символах (по умолчанию 1).
+	K int
+	// FoldCase включает приведение регистра по Unicode ("Bush" и "BUSH" совпадают).
+	FoldCase bool
+	// StripPunctuation удаляет знаки препинания и символы ("Bush," становится "Bush").
+	StripPunctuation bool
+	// Stopwords – слова, выбрасываемые после нормализации. nil – без фильтрации.
+	Stopwords map[string]bool
+}
+
+// EnglishStopwords – короткий список частотных английских слов.
+var EnglishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "by", "for", "from", "has", "have",
+	"he", "in", "is", "it", "its", "of", "on", "or", "said", "that", "the", "to",
+	"was", "were", "will", "with",
+}
+
+// NewStopwords собирает множество стоп-слов. Слова приводятся к нижнему регистру,
+// поэтому множество подходит для Tokenizer с FoldCase.
+func NewStopwords(words ...string) map[string]bool {
+	stopwords := make(map[string]bool, len(words))
+	for _, word := range words {
+		stopwords[foldString(word)] = true
+	}
+	return stopwords
+}
+
+// foldRune приводит символ к нижнему регистру через верхний, чтобы варианты
+// вроде 'ſ' или знака Кельвина совпадали с обычными буквами.
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func foldString(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// Words возвращает нормализованные слова текста в исходном порядке.
+func (t *Tokenizer) Words(text string) []string {
+	fields := strings.Fields(text)
+	words := fields[:0]
+	for _, word := range fields {
+		if t.FoldCase {
+			word = foldString(word)
+		}
+		if t.StripPunctuation {
+			word = strings.Map(func(r rune) rune {
+				if isPunctuation(r) {
+					return -1
+				}
+				return r
+			}, word)
+		}
+		if word == "" || t.Stopwords[word] {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// Shingles возвращает шинглы текста в порядке появления (с повторами).
+// Если текст короче K, весь текст считается одним шинглом.
+func (t *Tokenizer) Shingles(text string) []string {
+	k := t.K
+	if k < 1 {
+		k = 1
+	}
+	words := t.Words(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	if t.Mode == Chars {
+		runes := []rune(strings.Join(words, " "))
+		if len(runes) <= k {
+			return []string{string(runes)}
+		}
+		shingles := make([]string, 0, len(runes)-k+1)
+		for i := 0; i+k <= len(runes); i++ {
+			shingles = append(shingles, string(runes[i:i+k]))
+		}
+		return shingles
+	}
+
+	if len(words) <= k {
+		return []string{strings.Join(words, " ")}
+	}
+	shingles := make([]string, 0, len(words)-k+1)
+	for i := 0; i+k <= len(words); i++ {
+		shingles = append(shingles, strings.Join(words[i:i+k], " "))
+	}
+	return shingles
+}
+
+// HashedShingles возвращает множество 64-битных хэшей шинглов текста без повторов.
+func (t *Tokenizer) HashedShingles(text string) []uint64 {
+	shingles := t.Shingles(text)
+	seen := make(map[uint64]bool, len(shingles))
+	hashes := make([]uint64, 0, len(shingles))
+	for _, shingle := range shingles {
+		h := Hash(shingle)
+		if !seen[h] {
+			seen[h] = true
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes
+}
+
+// Hash – 64-битный FNV-1a хэш шингла.
+func Hash(shingle string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(shingle))
+	return h.Sum64()
+}
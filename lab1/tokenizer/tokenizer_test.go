@@ -0,0 +1,65 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer Tokenizer
+		text      string
+		expected  []string
+	}{
+		{"raw", Tokenizer{}, "Bush, said BUSH", []string{"Bush,", "said", "BUSH"}},
+		{"fold case", Tokenizer{FoldCase: true}, "Bush BUSH ſtar", []string{"bush", "bush", "star"}},
+		{"strip punctuation", Tokenizer{StripPunctuation: true}, "Bush, U.S. -- \"Bruni-Sarkozy\"", []string{"Bush", "US", "BruniSarkozy"}},
+		{"stopwords", Tokenizer{FoldCase: true, Stopwords: NewStopwords(EnglishStopwords...)}, "The President said it", []string{"president"}},
+		{"cyrillic", Tokenizer{FoldCase: true, StripPunctuation: true}, "Привет, МИР!", []string{"привет", "мир"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.tokenizer.Words(test.text); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestShingles(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer Tokenizer
+		text      string
+		expected  []string
+	}{
+		{"word 1-shingles", Tokenizer{Mode: Words}, "a b c", []string{"a", "b", "c"}},
+		{"word 2-shingles", Tokenizer{Mode: Words, K: 2}, "a b c", []string{"a b", "b c"}},
+		{"short text", Tokenizer{Mode: Words, K: 5}, "a b c", []string{"a b c"}},
+		{"char 3-shingles", Tokenizer{Mode: Chars, K: 3}, "ab  cd", []string{"ab ", "b c", " cd"}},
+		{"unicode chars", Tokenizer{Mode: Chars, K: 2}, "мир", []string{"ми", "ир"}},
+		{"empty", Tokenizer{Mode: Chars, K: 2}, "  ", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.tokenizer.Shingles(test.text); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestHashedShingles(t *testing.T) {
+	tok := Tokenizer{Mode: Words, FoldCase: true, StripPunctuation: true}
+
+	hashes := tok.HashedShingles("Bush, bush BUSH. Gonzales")
+	if len(hashes) != 2 {
+		t.Fatalf("Expected 2 distinct shingles, got %d", len(hashes))
+	}
+	if hashes[0] != Hash("bush") || hashes[1] != Hash("gonzales") {
+		t.Errorf("Unexpected hashes %v", hashes)
+	}
+}
@@ -6,92 +6,136 @@ import (
 	"strings"
 )
 
-type BTreeNode[T constraints.Ordered] struct {
+// node – узел B-дерева, общий для BTree и BTreeMap. Values[i] – значение ключа Keys[i];
+// в BTree значения пустые (struct{}) и памяти не занимают.
+type node[K constraints.Ordered, V any] struct {
 	Leaf     bool
-	Keys     []T
-	Children []*BTreeNode[T]
+	Keys     []K
+	Values   []V
+	Children []*node[K, V]
+	// Size – число ключей в поддереве узла (для Rank и Select).
+	Size int
+	cow  *cowToken // дерево-владелец; чужие узлы копируются перед изменением
 }
 
+// BTreeNode – узел BTree.
+type BTreeNode[T constraints.Ordered] = node[T, struct{}]
+
 type BTree[T constraints.Ordered] struct {
 	Root *BTreeNode[T]
 	t    int // минимальный порядок (t >= 2)
+	cow  *cowToken
 }
 
 func NewBTree[T constraints.Ordered](t int) *BTree[T] {
 	if t < 2 {
 		panic("Минимальный порядок B-Tree должен быть >= 2")
 	}
-	return &BTree[T]{Root: &BTreeNode[T]{Leaf: true}, t: t}
+	cow := new(cowToken)
+	return &BTree[T]{Root: &BTreeNode[T]{Leaf: true, cow: cow}, t: t, cow: cow}
 }
 
 // ---------- ВСТАВКА ----------
 
+// Insert добавляет ключ k. Повторяющиеся ключи хранятся столько раз, сколько вставлены.
 func (t *BTree[T]) Insert(k T) {
-	r := t.Root
-	if len(r.Keys) == 2*t.t-1 {
-		s := &BTreeNode[T]{Leaf: false, Children: []*BTreeNode[T]{r}}
-		t.Root = s
-		t.splitChild(s, 0)
-		t.insertNonFull(s, k)
-	} else {
-		t.insertNonFull(r, k)
+	t.Root, _, _ = insert(t.Root, k, struct{}{}, t.t, false, t.cow)
+}
+
+// insert вставляет k со значением v в дерево с корнем root порядка t и возвращает новый корень.
+// Если replace и ключ уже есть, вместо вставки заменяется его значение: тогда возвращаются
+// прежнее значение и true. Узлы, не принадлежащие cow, копируются, а не изменяются.
+func insert[K constraints.Ordered, V any](root *node[K, V], k K, v V, t int, replace bool, cow *cowToken) (*node[K, V], V, bool) {
+	if len(root.Keys) == 2*t-1 {
+		s := &node[K, V]{Leaf: false, Children: []*node[K, V]{root}, Size: root.Size, cow: cow}
+		s.splitChild(0, t, cow)
+		root = s
 	}
+	root = root.mutableFor(cow)
+	old, replaced := root.insertNonFull(k, v, t, replace, cow)
+	return root, old, replaced
 }
 
-func (t *BTree[T]) insertNonFull(x *BTreeNode[T], k T) {
-	i := len(x.Keys) - 1
+func (x *node[K, V]) insertNonFull(k K, v V, t int, replace bool, cow *cowToken) (V, bool) {
+	// i – первый ключ больше k: равные ключи остаются левее нового.
+	i := 0
+	for i < len(x.Keys) && k >= x.Keys[i] {
+		i++
+	}
+	if replace && i > 0 && x.Keys[i-1] == k {
+		old := x.Values[i-1]
+		x.Values[i-1] = v
+		return old, true
+	}
+
 	if x.Leaf {
-		x.Keys = append(x.Keys, k)
-		for i >= 0 && k < x.Keys[i] {
-			x.Keys[i+1] = x.Keys[i]
-			i--
+		x.Keys = append(x.Keys[:i], append([]K{k}, x.Keys[i:]...)...)
+		x.Values = append(x.Values[:i], append([]V{v}, x.Values[i:]...)...)
+		x.Size++
+		var zero V
+		return zero, false
+	}
+	if len(x.Children[i].Keys) == 2*t-1 {
+		x.splitChild(i, t, cow)
+		if replace && x.Keys[i] == k {
+			old := x.Values[i]
+			x.Values[i] = v
+			return old, true
 		}
-		x.Keys[i+1] = k
-	} else {
-		for i >= 0 && k < x.Keys[i] {
-			i--
+		if k > x.Keys[i] {
+			i++
 		}
-		i++
-		if len(x.Children[i].Keys) == 2*t.t-1 {
-			t.splitChild(x, i)
-			if k > x.Keys[i] {
-				i++
-			}
-		}
-		t.insertNonFull(x.Children[i], k)
 	}
+	old, replaced := x.mutableChild(i, cow).insertNonFull(k, v, t, replace, cow)
+	if !replaced {
+		x.Size++
+	}
+	return old, replaced
 }
 
-func (t *BTree[T]) splitChild(x *BTreeNode[T], i int) {
-	y := x.Children[i]
-	z := &BTreeNode[T]{Leaf: y.Leaf}
-	mid := t.t - 1
+func (x *node[K, V]) splitChild(i int, t int, cow *cowToken) {
+	y := x.mutableChild(i, cow)
+	z := &node[K, V]{Leaf: y.Leaf, cow: cow}
+	mid := t - 1
 
 	if len(y.Keys) <= mid {
 		panic(fmt.Sprintf("Cannot split: y.Keys too short (%d <= %d)", len(y.Keys), mid))
 	}
 
 	// Сохраняем midKey ДО обрезки y.Keys
-	midKey := y.Keys[mid]
+	midKey, midValue := y.Keys[mid], y.Values[mid]
 
-	// делим ключи
+	// делим ключи и значения
 	z.Keys = append(z.Keys, y.Keys[mid+1:]...)
+	z.Values = append(z.Values, y.Values[mid+1:]...)
 	y.Keys = y.Keys[:mid]
+	y.Values = y.Values[:mid]
 
 	// делим детей, если не лист
 	if !y.Leaf {
 		z.Children = append(z.Children, y.Children[mid+1:]...)
 		y.Children = y.Children[:mid+1]
 	}
+	z.recount()
+	y.Size -= z.Size + 1
 
 	// вставляем midKey в родителя
-	x.Keys = append(x.Keys[:i], append([]T{midKey}, x.Keys[i:]...)...)
-	x.Children = append(x.Children[:i+1], append([]*BTreeNode[T]{z}, x.Children[i+1:]...)...)
+	x.Keys = append(x.Keys[:i], append([]K{midKey}, x.Keys[i:]...)...)
+	x.Values = append(x.Values[:i], append([]V{midValue}, x.Values[i:]...)...)
+	x.Children = append(x.Children[:i+1], append([]*node[K, V]{z}, x.Children[i+1:]...)...)
+}
+
+// recount пересчитывает Size по ключам узла и размерам детей.
+func (n *node[K, V]) recount() {
+	n.Size = len(n.Keys)
+	for _, child := range n.Children {
+		n.Size += child.Size
+	}
 }
 
 // ---------- ПОИСК ----------
 
-func (n *BTreeNode[T]) Search(k T) (*BTreeNode[T], int) {
+func (n *node[K, V]) Search(k K) (*node[K, V], int) {
 	i := 0
 	for i < len(n.Keys) && k > n.Keys[i] {
 		i++
@@ -107,64 +151,76 @@ func (n *BTreeNode[T]) Search(k T) (*BTreeNode[T], int) {
 
 // ---------- УДАЛЕНИЕ ----------
 
+// Delete удаляет одно вхождение ключа k.
 func (t *BTree[T]) Delete(k T) {
 	if t.Root == nil {
 		return
 	}
-	t.Root.delete(k, t.t)
-	if len(t.Root.Keys) == 0 && !t.Root.Leaf {
-		t.Root = t.Root.Children[0]
+	t.Root, _, _ = remove(t.Root, k, t.t, t.cow)
+}
+
+// remove удаляет k из дерева с корнем root порядка t и возвращает новый корень,
+// значение удалённого ключа и признак того, что ключ был найден. Узлы, не принадлежащие cow,
+// копируются, а не изменяются.
+func remove[K constraints.Ordered, V any](root *node[K, V], k K, t int, cow *cowToken) (*node[K, V], V, bool) {
+	root = root.mutableFor(cow)
+	old, found := root.delete(k, t, cow)
+	if len(root.Keys) == 0 && !root.Leaf {
+		root = root.Children[0]
 	}
+	return root, old, found
 }
 
-func (n *BTreeNode[T]) delete(k T, t int) {
+func (n *node[K, V]) delete(k K, t int, cow *cowToken) (V, bool) {
+	old, found := n.deleteKey(k, t, cow)
+	if found {
+		n.Size--
+	}
+	return old, found
+}
+
+func (n *node[K, V]) deleteKey(k K, t int, cow *cowToken) (V, bool) {
 	idx := n.findKey(k)
 
 	if idx < len(n.Keys) && n.Keys[idx] == k {
+		old := n.Values[idx]
 		if n.Leaf {
 			n.Keys = append(n.Keys[:idx], n.Keys[idx+1:]...)
-		} else {
-			if idx+1 < len(n.Children) && len(n.Children[idx+1].Keys) >= t {
-				succ := n.Children[idx+1].getSuccessor()
-				n.Keys[idx] = succ
-				n.Children[idx+1].delete(succ, t)
-			} else if idx > 0 && len(n.Children[idx-1].Keys) >= t {
-				pred := n.Children[idx-1].getPredecessor()
-				n.Keys[idx] = pred
-				n.Children[idx-1].delete(pred, t)
-			} else {
-				var mergedIdx int
-				if idx < len(n.Children)-1 {
-					n.merge(idx)
-					mergedIdx = idx
-				} else {
-					mergedIdx = idx - 1
-					n.merge(mergedIdx)
-				}
-
-				// safety check
-				if mergedIdx >= 0 && mergedIdx < len(n.Children) {
-					n.Children[mergedIdx].delete(k, t)
-				}
-			}
-		}
-	} else {
-		if n.Leaf {
-			return
-		}
-		if idx >= len(n.Children) {
-			return // safety check
+			n.Values = append(n.Values[:idx], n.Values[idx+1:]...)
+			return old, true
 		}
-		if len(n.Children[idx].Keys) < t {
-			n.fill(idx, t)
-		}
-		if idx < len(n.Children) {
-			n.Children[idx].delete(k, t)
+		// У ключа внутреннего узла есть оба соседних ребёнка: idx и idx+1.
+		if len(n.Children[idx].Keys) >= t {
+			pred, predValue := n.Children[idx].getPredecessor()
+			n.Keys[idx], n.Values[idx] = pred, predValue
+			n.mutableChild(idx, cow).delete(pred, t, cow)
+		} else if len(n.Children[idx+1].Keys) >= t {
+			succ, succValue := n.Children[idx+1].getSuccessor()
+			n.Keys[idx], n.Values[idx] = succ, succValue
+			n.mutableChild(idx+1, cow).delete(succ, t, cow)
+		} else {
+			n.merge(idx, cow)
+			n.Children[idx].delete(k, t, cow)
 		}
+		return old, true
+	}
+
+	if n.Leaf {
+		var zero V
+		return zero, false
+	}
+	// Если последний ребёнок слит с предыдущим, ключ ищется в предыдущем.
+	last := idx == len(n.Keys)
+	if len(n.Children[idx].Keys) < t {
+		n.fill(idx, t, cow)
 	}
+	if last && idx > len(n.Keys) {
+		idx--
+	}
+	return n.mutableChild(idx, cow).delete(k, t, cow)
 }
 
-func (n *BTreeNode[T]) findKey(k T) int {
+func (n *node[K, V]) findKey(k K) int {
 	idx := 0
 	for idx < len(n.Keys) && n.Keys[idx] < k {
 		idx++
@@ -172,81 +228,101 @@ func (n *BTreeNode[T]) findKey(k T) int {
 	return idx
 }
 
-func (n *BTreeNode[T]) getPredecessor() T {
+func (n *node[K, V]) getPredecessor() (K, V) {
 	cur := n
 	for !cur.Leaf {
 		cur = cur.Children[len(cur.Children)-1]
 	}
-	return cur.Keys[len(cur.Keys)-1]
+	return cur.Keys[len(cur.Keys)-1], cur.Values[len(cur.Values)-1]
 }
 
-func (n *BTreeNode[T]) getSuccessor() T {
+func (n *node[K, V]) getSuccessor() (K, V) {
 	cur := n
 	for !cur.Leaf {
 		cur = cur.Children[0]
 	}
-	return cur.Keys[0]
+	return cur.Keys[0], cur.Values[0]
 }
 
-func (n *BTreeNode[T]) merge(idx int) {
-	child := n.Children[idx]
+// merge сливает ребёнка idx+1 в ребёнка idx; сам ребёнок idx+1 не изменяется.
+func (n *node[K, V]) merge(idx int, cow *cowToken) {
+	child := n.mutableChild(idx, cow)
 	sibling := n.Children[idx+1]
 
 	child.Keys = append(child.Keys, n.Keys[idx])
 	child.Keys = append(child.Keys, sibling.Keys...)
+	child.Values = append(child.Values, n.Values[idx])
+	child.Values = append(child.Values, sibling.Values...)
 
 	if !child.Leaf {
 		child.Children = append(child.Children, sibling.Children...)
 	}
+	child.Size += 1 + sibling.Size
 
 	n.Keys = append(n.Keys[:idx], n.Keys[idx+1:]...)
+	n.Values = append(n.Values[:idx], n.Values[idx+1:]...)
 	n.Children = append(n.Children[:idx+1], n.Children[idx+2:]...)
 }
 
-func (n *BTreeNode[T]) fill(idx int, t int) {
+func (n *node[K, V]) fill(idx int, t int, cow *cowToken) {
 	if idx != 0 && len(n.Children[idx-1].Keys) >= t {
-		n.borrowFromPrev(idx)
+		n.borrowFromPrev(idx, cow)
 	} else if idx != len(n.Children)-1 && len(n.Children[idx+1].Keys) >= t {
-		n.borrowFromNext(idx)
+		n.borrowFromNext(idx, cow)
 	} else {
 		if idx != len(n.Children)-1 {
-			n.merge(idx)
+			n.merge(idx, cow)
 		} else {
-			n.merge(idx - 1)
+			n.merge(idx-1, cow)
 		}
 	}
 }
 
-func (n *BTreeNode[T]) borrowFromPrev(idx int) {
-	child := n.Children[idx]
-	sibling := n.Children[idx-1]
+func (n *node[K, V]) borrowFromPrev(idx int, cow *cowToken) {
+	child := n.mutableChild(idx, cow)
+	sibling := n.mutableChild(idx-1, cow)
 
-	child.Keys = append([]T{n.Keys[idx-1]}, child.Keys...)
+	child.Keys = append([]K{n.Keys[idx-1]}, child.Keys...)
+	child.Values = append([]V{n.Values[idx-1]}, child.Values...)
+	moved := 1
 	if !child.Leaf {
-		child.Children = append([]*BTreeNode[T]{sibling.Children[len(sibling.Children)-1]}, child.Children...)
+		grandchild := sibling.Children[len(sibling.Children)-1]
+		child.Children = append([]*node[K, V]{grandchild}, child.Children...)
 		sibling.Children = sibling.Children[:len(sibling.Children)-1]
+		moved += grandchild.Size
 	}
+	child.Size += moved
+	sibling.Size -= moved
 
-	n.Keys[idx-1] = sibling.Keys[len(sibling.Keys)-1]
-	sibling.Keys = sibling.Keys[:len(sibling.Keys)-1]
+	last := len(sibling.Keys) - 1
+	n.Keys[idx-1], n.Values[idx-1] = sibling.Keys[last], sibling.Values[last]
+	sibling.Keys = sibling.Keys[:last]
+	sibling.Values = sibling.Values[:last]
 }
 
-func (n *BTreeNode[T]) borrowFromNext(idx int) {
-	child := n.Children[idx]
-	sibling := n.Children[idx+1]
+func (n *node[K, V]) borrowFromNext(idx int, cow *cowToken) {
+	child := n.mutableChild(idx, cow)
+	sibling := n.mutableChild(idx+1, cow)
 
 	child.Keys = append(child.Keys, n.Keys[idx])
-	n.Keys[idx] = sibling.Keys[0]
+	child.Values = append(child.Values, n.Values[idx])
+	n.Keys[idx], n.Values[idx] = sibling.Keys[0], sibling.Values[0]
 	sibling.Keys = sibling.Keys[1:]
+	sibling.Values = sibling.Values[1:]
 
+	moved := 1
 	if !child.Leaf {
-		child.Children = append(child.Children, sibling.Children[0])
+		grandchild := sibling.Children[0]
+		child.Children = append(child.Children, grandchild)
 		sibling.Children = sibling.Children[1:]
+		moved += grandchild.Size
 	}
+	child.Size += moved
+	sibling.Size -= moved
 }
 
 // ---------- ПЕЧАТЬ ----------
-func (n *BTreeNode[T]) Print(level int) {
+func (n *node[K, V]) Print(level int) {
 	fmt.Printf("%s%v\n", spaces(level), n.Keys)
 	if !n.Leaf {
 		for _, child := range n.Children {
@@ -3,11 +3,14 @@ package B_Tree
 import (
 	"encoding/csv"
 	"fmt"
+	"math/rand"
 	"os"
 	"sort"
 	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/exp/constraints"
 )
 
 // ---------- CSV Загрузка ----------
@@ -81,6 +84,33 @@ func TestBTree_DeleteAndSearch(t *testing.T) {
 	}
 }
 
+// TestBTree_DeleteAll удаляет все ключи в случайном порядке, проверяя структуру дерева
+func TestBTree_DeleteAll(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{2, 3, 4} {
+		tree := NewBTree[int](order)
+		values := rng.Perm(1000)
+		for _, v := range values {
+			tree.Insert(v)
+		}
+		checkInvariants(t, tree.Root, order)
+
+		rng.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+		for n, v := range values {
+			tree.Delete(v)
+			if node, _ := tree.Root.Search(v); node != nil {
+				t.Fatalf("t=%d: Delete(%d) failed: still found", order, v)
+			}
+			if n%100 == 0 {
+				checkInvariants(t, tree.Root, order)
+			}
+		}
+		if len(tree.Root.Keys) != 0 || !tree.Root.Leaf {
+			t.Errorf("t=%d: expected empty tree, got %v", order, tree.Root.Keys)
+		}
+	}
+}
+
 func TestBTree_DuplicateInsert(t *testing.T) {
 	tree := NewBTree[int](2)
 	tree.Insert(10)
@@ -104,6 +134,59 @@ func TestBTree_PrintAfterInsert(t *testing.T) {
 	tree.Root.Print(0)
 }
 
+// ---------- Проверка структуры ----------
+
+// checkInvariants проверяет свойства B-дерева порядка t: ключи упорядочены и лежат
+// между ключами родителя, у некорневых узлов от t-1 до 2t-1 ключей,
+// у каждого ключа есть значение, Size равен числу ключей поддерева и все листья на одной глубине.
+func checkInvariants[K constraints.Ordered, V any](tb testing.TB, root *node[K, V], t int) {
+	tb.Helper()
+	leafDepth := -1
+	var walk func(n *node[K, V], depth int, lo, hi *K)
+	walk = func(n *node[K, V], depth int, lo, hi *K) {
+		if n != root && (len(n.Keys) < t-1 || len(n.Keys) > 2*t-1) {
+			tb.Fatalf("Node %v has %d keys, want [%d, %d]", n.Keys, len(n.Keys), t-1, 2*t-1)
+		}
+		if len(n.Values) != len(n.Keys) {
+			tb.Fatalf("Node %v has %d values", n.Keys, len(n.Values))
+		}
+		size := len(n.Keys)
+		for _, child := range n.Children {
+			size += child.Size
+		}
+		if n.Size != size {
+			tb.Fatalf("Node %v has Size %d, want %d", n.Keys, n.Size, size)
+		}
+		for i, k := range n.Keys {
+			if (i > 0 && k < n.Keys[i-1]) || (lo != nil && k < *lo) || (hi != nil && k > *hi) {
+				tb.Fatalf("Node %v is out of order", n.Keys)
+			}
+		}
+		if n.Leaf {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if depth != leafDepth {
+				tb.Fatalf("Leaves at depths %d and %d", leafDepth, depth)
+			}
+			return
+		}
+		if len(n.Children) != len(n.Keys)+1 {
+			tb.Fatalf("Node %v has %d children", n.Keys, len(n.Children))
+		}
+		for i, child := range n.Children {
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &n.Keys[i-1]
+			}
+			if i < len(n.Keys) {
+				childHi = &n.Keys[i]
+			}
+			walk(child, depth+1, childLo, childHi)
+		}
+	}
+	walk(root, 0, nil, nil)
+}
+
 // ---------- Вспомогательная функция ----------
 
 func computeStats(durations []time.Duration) (mean, q1, median, q3 time.Duration) {
@@ -229,3 +312,45 @@ func BenchmarkBTree_DeleteFromCSV(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkBTree_PrefixFromCSV(b *testing.B) {
+	sizes := []int{1000, 10000, 100000}
+	values, err := LoadDescriptionsFromCSV("EDAresult.csv")
+	if err != nil {
+		b.Fatalf("failed to load CSV: %v", err)
+	}
+
+	for _, size := range sizes {
+		if len(values) < size {
+			b.Skipf("CSV does not contain %d entries", size)
+			continue
+		}
+
+		b.Run(fmt.Sprintf("Prefix-%d", size), func(b *testing.B) {
+			tree := NewBTree[string](20)
+
+			for _, val := range values[:size] {
+				tree.Insert(val)
+			}
+
+			durations := make([]time.Duration, 0, 1000)
+			matched := 0
+			startAll := time.Now()
+			for i := 0; i < 1000; i++ {
+				// Префикс – первые несколько символов существующего описания.
+				prefix := values[i%size]
+				if len(prefix) > 3 {
+					prefix = prefix[:3]
+				}
+				startOp := time.Now()
+				for range Prefix(tree, prefix) {
+					matched++
+				}
+				durations = append(durations, time.Since(startOp))
+			}
+			total := time.Since(startAll)
+			mean, q1, median, q3 := computeStats(durations)
+			b.Logf("Prefix %d items: matched=%d total=%v mean=%v q1=%v median=%v q3=%v", size, matched, total, mean, q1, median, q3)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package B_Tree
+
+import "golang.org/x/exp/constraints"
+
+// BTreeMap – упорядоченное отображение ключей в значения на том же B-дереве, что и BTree.
+// Ключи уникальны: Put существующего ключа заменяет его значение.
+type BTreeMap[K constraints.Ordered, V any] struct {
+	root *node[K, V]
+	t    int // минимальный порядок (t >= 2)
+	cow  *cowToken
+}
+
+func NewBTreeMap[K constraints.Ordered, V any](t int) *BTreeMap[K, V] {
+	if t < 2 {
+		panic("Минимальный порядок B-Tree должен быть >= 2")
+	}
+	cow := new(cowToken)
+	return &BTreeMap[K, V]{root: &node[K, V]{Leaf: true, cow: cow}, t: t, cow: cow}
+}
+
+// Put связывает ключ k со значением v. Если ключ уже был, возвращаются прежнее значение и true.
+func (m *BTreeMap[K, V]) Put(k K, v V) (V, bool) {
+	var old V
+	var replaced bool
+	m.root, old, replaced = insert(m.root, k, v, m.t, true, m.cow)
+	return old, replaced
+}
+
+// Get возвращает значение ключа k и признак его наличия.
+func (m *BTreeMap[K, V]) Get(k K) (V, bool) {
+	node, i := m.root.Search(k)
+	if node == nil {
+		var zero V
+		return zero, false
+	}
+	return node.Values[i], true
+}
+
+// Delete удаляет ключ k и возвращает его значение и признак того, что ключ был.
+func (m *BTreeMap[K, V]) Delete(k K) (V, bool) {
+	var old V
+	var found bool
+	m.root, old, found = remove(m.root, k, m.t, m.cow)
+	return old, found
+}
+
+// Len возвращает число ключей.
+func (m *BTreeMap[K, V]) Len() int {
+	return m.root.Size
+}
@@ -0,0 +1,76 @@
+package B_Tree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBTreeMap_PutGetDelete(t *testing.T) {
+	m := NewBTreeMap[string, int](2)
+
+	if _, replaced := m.Put("a", 1); replaced {
+		t.Error("Put(a) on empty map reported a replace")
+	}
+	m.Put("b", 2)
+	if old, replaced := m.Put("a", 10); !replaced || old != 1 {
+		t.Errorf("Put(a) again: expected old value 1, got %d (%v)", old, replaced)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %d", m.Len())
+	}
+	if v, ok := m.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a): expected 10, got %d (%v)", v, ok)
+	}
+	if _, ok := m.Get("c"); ok {
+		t.Error("Get(c) found a missing key")
+	}
+
+	if old, ok := m.Delete("b"); !ok || old != 2 {
+		t.Errorf("Delete(b): expected 2, got %d (%v)", old, ok)
+	}
+	if _, ok := m.Delete("b"); ok {
+		t.Error("Delete(b) twice reported success")
+	}
+	if m.Len() != 1 {
+		t.Errorf("Expected 1 key, got %d", m.Len())
+	}
+}
+
+// TestBTreeMap_Random сравнивает BTreeMap со встроенной map на случайных операциях
+func TestBTreeMap_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{2, 3, 5} {
+		m := NewBTreeMap[int, int](order)
+		model := make(map[int]int)
+
+		for op := 0; op < 20000; op++ {
+			k := rng.Intn(500)
+			switch rng.Intn(3) {
+			case 0, 1:
+				old, replaced := m.Put(k, op)
+				want, exists := model[k]
+				if replaced != exists || old != want {
+					t.Fatalf("t=%d Put(%d): got (%d, %v), want (%d, %v)", order, k, old, replaced, want, exists)
+				}
+				model[k] = op
+			case 2:
+				old, found := m.Delete(k)
+				want, exists := model[k]
+				if found != exists || old != want {
+					t.Fatalf("t=%d Delete(%d): got (%d, %v), want (%d, %v)", order, k, old, found, want, exists)
+				}
+				delete(model, k)
+			}
+			if m.Len() != len(model) {
+				t.Fatalf("t=%d: Len %d, want %d", order, m.Len(), len(model))
+			}
+		}
+
+		checkInvariants(t, m.root, order)
+		for k, want := range model {
+			if v, ok := m.Get(k); !ok || v != want {
+				t.Fatalf("t=%d Get(%d): got (%d, %v), want %d", order, k, v, ok, want)
+			}
+		}
+	}
+}
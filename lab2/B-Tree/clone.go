@@ -0,0 +1,47 @@
+package B_Tree
+
+// Копирование при записи: каждое дерево помечает созданные им узлы своей меткой cowToken.
+// Узел с чужой меткой может быть общим с другим деревом, поэтому перед изменением он
+// копируется вместе с путём от корня (path copying). Clone раздаёт обоим деревьям новые метки,
+// и все существующие узлы становятся общими: их не изменяет ни одно дерево.
+
+// cowToken – метка владельца узлов. Поле нужно, чтобы указатели на разные метки различались.
+type cowToken struct{ _ int }
+
+// mutableFor возвращает узел, который дерево с меткой cow может изменять: сам n,
+// если узел принадлежит дереву, иначе его копию.
+func (n *node[K, V]) mutableFor(cow *cowToken) *node[K, V] {
+	if n.cow == cow {
+		return n
+	}
+	return &node[K, V]{
+		Leaf:     n.Leaf,
+		Keys:     append([]K(nil), n.Keys...),
+		Values:   append([]V(nil), n.Values...),
+		Children: append([]*node[K, V](nil), n.Children...),
+		Size:     n.Size,
+		cow:      cow,
+	}
+}
+
+// mutableChild делает изменяемым ребёнка i изменяемого узла n и возвращает его.
+func (n *node[K, V]) mutableChild(i int, cow *cowToken) *node[K, V] {
+	child := n.Children[i].mutableFor(cow)
+	n.Children[i] = child
+	return child
+}
+
+// Clone возвращает независимую копию дерева за O(1). Узлы остаются общими и копируются
+// при первом изменении в любой из копий, поэтому изменение одной копии не видно в другой.
+// Сам вызов Clone меняет t, но после него копию можно читать в другой горутине,
+// пока t изменяется, – так читатели получают согласованный снимок без блокировки.
+func (t *BTree[T]) Clone() *BTree[T] {
+	t.cow = new(cowToken)
+	return &BTree[T]{Root: t.Root, t: t.t, cow: new(cowToken)}
+}
+
+// Clone возвращает независимую копию отображения за O(1); см. BTree.Clone.
+func (m *BTreeMap[K, V]) Clone() *BTreeMap[K, V] {
+	m.cow = new(cowToken)
+	return &BTreeMap[K, V]{root: m.root, t: m.t, cow: new(cowToken)}
+}
@@ -0,0 +1,127 @@
+package B_Tree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestBTree_CloneIsolation сохраняет снимки дерева по ходу случайных вставок и удалений,
+// продолжает изменять и оригинал, и снимки, и проверяет, что каждая копия совпадает
+// только со своей моделью
+func TestBTree_CloneIsolation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{2, 3, 5} {
+		type copyOf struct {
+			tree  *BTree[int]
+			model []int
+		}
+		copies := []*copyOf{{tree: NewBTree[int](order)}}
+
+		for op := 0; op < 4000; op++ {
+			c := copies[rng.Intn(len(copies))]
+			if op%200 == 0 {
+				copies = append(copies, &copyOf{tree: c.tree.Clone(), model: slices.Clone(c.model)})
+			}
+
+			k := rng.Intn(200)
+			if rng.Intn(3) == 0 {
+				c.tree.Delete(k)
+				if i, found := slices.BinarySearch(c.model, k); found {
+					c.model = slices.Delete(c.model, i, i+1)
+				}
+			} else {
+				c.tree.Insert(k)
+				i, _ := slices.BinarySearch(c.model, k)
+				c.model = slices.Insert(c.model, i, k)
+			}
+		}
+
+		for i, c := range copies {
+			checkInvariants(t, c.tree.Root, order)
+			if got := slices.Collect(c.tree.All()); !slices.Equal(got, c.model) {
+				t.Fatalf("t=%d: copy %d has %d keys, its model has %d", order, i, len(got), len(c.model))
+			}
+		}
+	}
+}
+
+func TestBTreeMap_Clone(t *testing.T) {
+	m := NewBTreeMap[int, string](2)
+	for k := 0; k < 100; k++ {
+		m.Put(k, "original")
+	}
+	snapshot := m.Clone()
+
+	for k := 0; k < 100; k += 2 {
+		m.Put(k, "changed")
+	}
+	for k := 1; k < 100; k += 4 {
+		m.Delete(k)
+	}
+	snapshot.Put(1000, "snapshot only")
+
+	if snapshot.Len() != 101 {
+		t.Errorf("Snapshot Len = %d, want 101", snapshot.Len())
+	}
+	for k := 0; k < 100; k++ {
+		if v, ok := snapshot.Get(k); !ok || v != "original" {
+			t.Fatalf("Snapshot Get(%d) = (%q, %v), want original", k, v, ok)
+		}
+	}
+	if _, ok := m.Get(1000); ok {
+		t.Error("Key put into the snapshot appeared in the original")
+	}
+	if v, _ := m.Get(4); v != "changed" {
+		t.Errorf("Original Get(4) = %q, want changed", v)
+	}
+	if _, ok := m.Get(5); ok {
+		t.Error("Deleted key 5 is still in the original")
+	}
+}
+
+// TestBTree_CloneConcurrentReads читает снимки в отдельных горутинах, пока писатель
+// изменяет дерево. Гонки обнаруживаются при запуске с -race.
+func TestBTree_CloneConcurrentReads(t *testing.T) {
+	tree := NewBTree[int](3)
+	for k := 0; k < 1000; k++ {
+		tree.Insert(k)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 10)
+	for r := 0; r < 10; r++ {
+		snapshot := tree.Clone()
+		want := snapshot.Len()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				count, prev := 0, -1
+				for k := range snapshot.All() {
+					if k < prev {
+						errs <- "snapshot keys out of order"
+						return
+					}
+					prev = k
+					count++
+				}
+				if count != want || snapshot.Len() != want {
+					errs <- "snapshot changed while the writer was running"
+					return
+				}
+			}
+		}()
+
+		for k := 0; k < 100; k++ {
+			tree.Insert(1000 + r*100 + k)
+			tree.Delete(r*100 + k)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,224 @@
+package B_Tree
+
+import (
+	"iter"
+	"strings"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Bounds задаёт, входят ли границы lo и hi в диапазон Range.
+type Bounds uint8
+
+const (
+	Closed     Bounds = iota // [lo, hi]
+	ClosedOpen               // [lo, hi)
+	OpenClosed               // (lo, hi]
+	Open                     // (lo, hi)
+)
+
+// LoInclusive сообщает, входит ли нижняя граница в диапазон.
+func (b Bounds) LoInclusive() bool { return b == Closed || b == ClosedOpen }
+
+// HiInclusive сообщает, входит ли верхняя граница в диапазон.
+func (b Bounds) HiInclusive() bool { return b == Closed || b == OpenClosed }
+
+// ---------- ОБХОД УЗЛОВ ----------
+
+// ascend передаёт yield ключи поддерева по возрастанию, начиная с lo (с самого меньшего,
+// если lo == nil), пока yield возвращает true. Возвращает false, если обход прерван.
+func (n *node[K, V]) ascend(lo *K, inclusive bool, yield func(K, V) bool) bool {
+	// i – первый ключ узла, не меньший lo (больший lo, если граница не входит).
+	i := 0
+	for lo != nil && i < len(n.Keys) && (n.Keys[i] < *lo || !inclusive && n.Keys[i] == *lo) {
+		i++
+	}
+	// Только ребёнок i может содержать ключи по обе стороны от lo.
+	if !n.Leaf && !n.Children[i].ascend(lo, inclusive, yield) {
+		return false
+	}
+	for ; i < len(n.Keys); i++ {
+		if !yield(n.Keys[i], n.Values[i]) {
+			return false
+		}
+		if !n.Leaf && !n.Children[i+1].ascend(nil, false, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// descend передаёт yield ключи поддерева по убыванию, начиная с hi (с самого большего,
+// если hi == nil), пока yield возвращает true. Возвращает false, если обход прерван.
+func (n *node[K, V]) descend(hi *K, inclusive bool, yield func(K, V) bool) bool {
+	// i – число ключей узла, не больших hi (меньших hi, если граница не входит).
+	i := len(n.Keys)
+	for hi != nil && i > 0 && (n.Keys[i-1] > *hi || !inclusive && n.Keys[i-1] == *hi) {
+		i--
+	}
+	if !n.Leaf && !n.Children[i].descend(hi, inclusive, yield) {
+		return false
+	}
+	for i--; i >= 0; i-- {
+		if !yield(n.Keys[i], n.Values[i]) {
+			return false
+		}
+		if !n.Leaf && !n.Children[i].descend(nil, false, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeSeq обходит ключи из диапазона lo..hi с границами bounds.
+func (n *node[K, V]) rangeSeq(lo, hi K, bounds Bounds, yield func(K, V) bool) {
+	n.ascend(&lo, bounds.LoInclusive(), func(k K, v V) bool {
+		if k > hi || !bounds.HiInclusive() && k == hi {
+			return false
+		}
+		return yield(k, v)
+	})
+}
+
+// first возвращает первый ключ обхода от границы bound.
+func first[K constraints.Ordered, V any](walk func(bound *K, inclusive bool, yield func(K, V) bool) bool, bound *K, inclusive bool) (K, V, bool) {
+	var key K
+	var value V
+	found := false
+	walk(bound, inclusive, func(k K, v V) bool {
+		key, value, found = k, v, true
+		return false
+	})
+	return key, value, found
+}
+
+// ---------- BTree ----------
+
+// All обходит ключи по возрастанию. Изменять дерево во время обхода нельзя –
+// это относится ко всем итераторам пакета.
+func (t *BTree[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Root.ascend(nil, false, func(k T, _ struct{}) bool { return yield(k) })
+	}
+}
+
+// Backward обходит ключи по убыванию.
+func (t *BTree[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Root.descend(nil, false, func(k T, _ struct{}) bool { return yield(k) })
+	}
+}
+
+// Range обходит по возрастанию ключи между lo и hi; bounds задаёт, входят ли границы.
+func (t *BTree[T]) Range(lo, hi T, bounds Bounds) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.Root.rangeSeq(lo, hi, bounds, func(k T, _ struct{}) bool { return yield(k) })
+	}
+}
+
+// Min возвращает наименьший ключ; false, если дерево пусто.
+func (t *BTree[T]) Min() (T, bool) {
+	k, _, ok := first(t.Root.ascend, nil, false)
+	return k, ok
+}
+
+// Max возвращает наибольший ключ; false, если дерево пусто.
+func (t *BTree[T]) Max() (T, bool) {
+	k, _, ok := first(t.Root.descend, nil, false)
+	return k, ok
+}
+
+// Floor возвращает наибольший ключ, не больший k.
+func (t *BTree[T]) Floor(k T) (T, bool) {
+	key, _, ok := first(t.Root.descend, &k, true)
+	return key, ok
+}
+
+// Ceiling возвращает наименьший ключ, не меньший k.
+func (t *BTree[T]) Ceiling(k T) (T, bool) {
+	key, _, ok := first(t.Root.ascend, &k, true)
+	return key, ok
+}
+
+// Predecessor возвращает наибольший ключ, строго меньший k.
+func (t *BTree[T]) Predecessor(k T) (T, bool) {
+	key, _, ok := first(t.Root.descend, &k, false)
+	return key, ok
+}
+
+// Successor возвращает наименьший ключ, строго больший k.
+func (t *BTree[T]) Successor(k T) (T, bool) {
+	key, _, ok := first(t.Root.ascend, &k, false)
+	return key, ok
+}
+
+// Prefix обходит по возрастанию строки дерева, начинающиеся с prefix.
+func Prefix(t *BTree[string], prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		t.Root.ascend(&prefix, true, func(k string, _ struct{}) bool {
+			return strings.HasPrefix(k, prefix) && yield(k)
+		})
+	}
+}
+
+// ---------- BTreeMap ----------
+
+// All обходит пары по возрастанию ключей.
+func (m *BTreeMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.ascend(nil, false, yield)
+	}
+}
+
+// Backward обходит пары по убыванию ключей.
+func (m *BTreeMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.descend(nil, false, yield)
+	}
+}
+
+// Range обходит по возрастанию пары с ключами между lo и hi; bounds задаёт, входят ли границы.
+func (m *BTreeMap[K, V]) Range(lo, hi K, bounds Bounds) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.rangeSeq(lo, hi, bounds, yield)
+	}
+}
+
+// Min возвращает пару с наименьшим ключом; false, если отображение пусто.
+func (m *BTreeMap[K, V]) Min() (K, V, bool) {
+	return first(m.root.ascend, nil, false)
+}
+
+// Max возвращает пару с наибольшим ключом; false, если отображение пусто.
+func (m *BTreeMap[K, V]) Max() (K, V, bool) {
+	return first(m.root.descend, nil, false)
+}
+
+// Floor возвращает пару с наибольшим ключом, не большим k.
+func (m *BTreeMap[K, V]) Floor(k K) (K, V, bool) {
+	return first(m.root.descend, &k, true)
+}
+
+// Ceiling возвращает пару с наименьшим ключом, не меньшим k.
+func (m *BTreeMap[K, V]) Ceiling(k K) (K, V, bool) {
+	return first(m.root.ascend, &k, true)
+}
+
+// Predecessor возвращает пару с наибольшим ключом, строго меньшим k.
+func (m *BTreeMap[K, V]) Predecessor(k K) (K, V, bool) {
+	return first(m.root.descend, &k, false)
+}
+
+// Successor возвращает пару с наименьшим ключом, строго большим k.
+func (m *BTreeMap[K, V]) Successor(k K) (K, V, bool) {
+	return first(m.root.ascend, &k, false)
+}
+
+// MapPrefix обходит по возрастанию пары отображения, ключи которых начинаются с prefix.
+func MapPrefix[V any](m *BTreeMap[string, V], prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		m.root.ascend(&prefix, true, func(k string, v V) bool {
+			return strings.HasPrefix(k, prefix) && yield(k, v)
+		})
+	}
+}
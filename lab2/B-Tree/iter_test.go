@@ -0,0 +1,151 @@
+package B_Tree
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// TestBTree_Iterators сравнивает обходы и запросы соседей с отсортированным срезом
+func TestBTree_Iterators(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{2, 3, 6} {
+		tree := NewBTree[int](order)
+		var model []int
+		for _, v := range rng.Perm(600) {
+			if v%3 != 0 { // пропуски, чтобы запросы попадали между ключами
+				tree.Insert(v)
+				model = append(model, v)
+			}
+		}
+		sort.Ints(model)
+
+		if got := slices.Collect(tree.All()); !slices.Equal(got, model) {
+			t.Fatalf("t=%d: All differs from model", order)
+		}
+		backward := slices.Clone(model)
+		slices.Reverse(backward)
+		if got := slices.Collect(tree.Backward()); !slices.Equal(got, backward) {
+			t.Fatalf("t=%d: Backward differs from model", order)
+		}
+		if k, _ := tree.Min(); k != model[0] {
+			t.Errorf("t=%d: Min %d, want %d", order, k, model[0])
+		}
+		if k, _ := tree.Max(); k != model[len(model)-1] {
+			t.Errorf("t=%d: Max %d, want %d", order, k, model[len(model)-1])
+		}
+
+		for q := -2; q < 602; q++ {
+			checkNeighbor(t, "Floor", q, tree.Floor, model, func(k int) bool { return k <= q }, true)
+			checkNeighbor(t, "Predecessor", q, tree.Predecessor, model, func(k int) bool { return k < q }, true)
+			checkNeighbor(t, "Ceiling", q, tree.Ceiling, model, func(k int) bool { return k >= q }, false)
+			checkNeighbor(t, "Successor", q, tree.Successor, model, func(k int) bool { return k > q }, false)
+		}
+
+		for i := 0; i < 200; i++ {
+			lo, hi := rng.Intn(620)-10, rng.Intn(620)-10
+			for _, bounds := range []Bounds{Closed, ClosedOpen, OpenClosed, Open} {
+				var want []int
+				for _, k := range model {
+					if (k > lo || bounds.LoInclusive() && k == lo) && (k < hi || bounds.HiInclusive() && k == hi) {
+						want = append(want, k)
+					}
+				}
+				if got := slices.Collect(tree.Range(lo, hi, bounds)); !slices.Equal(got, want) {
+					t.Fatalf("t=%d: Range(%d, %d, %d) = %v, want %v", order, lo, hi, bounds, got, want)
+				}
+			}
+		}
+	}
+}
+
+// checkNeighbor сравнивает запрос соседа с линейным поиском в model: last выбирает
+// наибольший подходящий ключ, иначе наименьший.
+func checkNeighbor(t *testing.T, name string, q int, query func(int) (int, bool), model []int, match func(int) bool, last bool) {
+	t.Helper()
+	want, exists := 0, false
+	for _, k := range model {
+		if match(k) && (!exists || last) {
+			want, exists = k, true
+		}
+	}
+	if got, ok := query(q); ok != exists || got != want {
+		t.Fatalf("%s(%d) = (%d, %v), want (%d, %v)", name, q, got, ok, want, exists)
+	}
+}
+
+func TestBTree_IteratorBreak(t *testing.T) {
+	tree := NewBTree[int](2)
+	for v := 0; v < 100; v++ {
+		tree.Insert(v)
+	}
+	var got []int
+	for k := range tree.All() {
+		if k == 5 {
+			break
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("Expected 0..4 before break, got %v", got)
+	}
+
+	empty := NewBTree[int](2)
+	if _, ok := empty.Min(); ok {
+		t.Error("Min of empty tree reported a key")
+	}
+	if got := slices.Collect(empty.Range(0, 10, Closed)); len(got) != 0 {
+		t.Errorf("Range of empty tree returned %v", got)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tree := NewBTree[string](2)
+	m := NewBTreeMap[string, int](2)
+	words := []string{"car", "card", "care", "cargo", "cat", "ca", "c", "dog", "carb"}
+	for i, w := range words {
+		tree.Insert(w)
+		m.Put(w, i)
+	}
+
+	want := []string{"car", "carb", "card", "care", "cargo"}
+	if got := slices.Collect(Prefix(tree, "car")); !slices.Equal(got, want) {
+		t.Errorf("Prefix(car) = %v, want %v", got, want)
+	}
+	var keys []string
+	for k, v := range MapPrefix(m, "car") {
+		if words[v] != k {
+			t.Errorf("MapPrefix: key %q has value %d", k, v)
+		}
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("MapPrefix(car) = %v, want %v", keys, want)
+	}
+	if got := slices.Collect(Prefix(tree, "x")); len(got) != 0 {
+		t.Errorf("Prefix(x) = %v, want none", got)
+	}
+	if got := slices.Collect(Prefix(tree, "")); len(got) != len(words) {
+		t.Errorf("Prefix(\"\") returned %d keys, want %d", len(got), len(words))
+	}
+}
+
+func TestBTreeMap_Range(t *testing.T) {
+	m := NewBTreeMap[int, string](3)
+	for v := 0; v < 50; v++ {
+		m.Put(v, fmt.Sprint(v))
+	}
+	for k, v := range m.Range(10, 15, ClosedOpen) {
+		if v != fmt.Sprint(k) || k < 10 || k >= 15 {
+			t.Errorf("Range(10, 15): unexpected pair %d=%q", k, v)
+		}
+	}
+	if k, v, ok := m.Floor(100); !ok || k != 49 || v != "49" {
+		t.Errorf("Floor(100) = (%d, %q, %v)", k, v, ok)
+	}
+	if _, _, ok := m.Predecessor(0); ok {
+		t.Error("Predecessor(0) found a key")
+	}
+}
@@ -0,0 +1,98 @@
+package B_Tree
+
+// Порядковые статистики считаются по Size поддеревьев за O(t log n) без обхода ключей.
+
+// countBefore возвращает число ключей поддерева, меньших k (не больших k, если inclusive).
+func (n *node[K, V]) countBefore(k K, inclusive bool) int {
+	count := 0
+	cur := n
+	for {
+		i := 0
+		for i < len(cur.Keys) && (cur.Keys[i] < k || inclusive && cur.Keys[i] == k) {
+			if !cur.Leaf {
+				count += cur.Children[i].Size
+			}
+			i++
+		}
+		count += i
+		if cur.Leaf {
+			return count
+		}
+		// Ребёнок i может содержать ключи по обе стороны от k.
+		cur = cur.Children[i]
+	}
+}
+
+// selectKey возвращает ключ с порядковым номером i (с нуля) в поддереве.
+func (n *node[K, V]) selectKey(i int) (K, V, bool) {
+	if i < 0 || i >= n.Size {
+		var key K
+		var value V
+		return key, value, false
+	}
+	cur := n
+descend:
+	for !cur.Leaf {
+		for j, child := range cur.Children {
+			if i < child.Size {
+				cur = child
+				continue descend
+			}
+			i -= child.Size
+			if i == 0 {
+				return cur.Keys[j], cur.Values[j], true
+			}
+			i--
+		}
+	}
+	return cur.Keys[i], cur.Values[i], true
+}
+
+// countRange возвращает число ключей между lo и hi с границами bounds.
+func (n *node[K, V]) countRange(lo, hi K, bounds Bounds) int {
+	count := n.countBefore(hi, bounds.HiInclusive()) - n.countBefore(lo, !bounds.LoInclusive())
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// ---------- BTree ----------
+
+// Len возвращает число ключей с учётом повторов.
+func (t *BTree[T]) Len() int {
+	return t.Root.Size
+}
+
+// Rank возвращает число ключей, меньших k, – позицию k в отсортированном порядке.
+func (t *BTree[T]) Rank(k T) int {
+	return t.Root.countBefore(k, false)
+}
+
+// Select возвращает ключ с порядковым номером i (с нуля); false, если i вне [0, Len()).
+func (t *BTree[T]) Select(i int) (T, bool) {
+	k, _, ok := t.Root.selectKey(i)
+	return k, ok
+}
+
+// CountRange возвращает число ключей между lo и hi; bounds задаёт, входят ли границы.
+func (t *BTree[T]) CountRange(lo, hi T, bounds Bounds) int {
+	return t.Root.countRange(lo, hi, bounds)
+}
+
+// ---------- BTreeMap ----------
+
+// Rank возвращает число ключей, меньших k.
+func (m *BTreeMap[K, V]) Rank(k K) int {
+	return m.root.countBefore(k, false)
+}
+
+// Select возвращает пару с порядковым номером i (с нуля); false, если i вне [0, Len()).
+func (m *BTreeMap[K, V]) Select(i int) (K, V, bool) {
+	return m.root.selectKey(i)
+}
+
+// CountRange возвращает число ключей между lo и hi; bounds задаёт, входят ли границы.
+func (m *BTreeMap[K, V]) CountRange(lo, hi K, bounds Bounds) int {
+	return m.root.countRange(lo, hi, bounds)
+}
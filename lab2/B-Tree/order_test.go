@@ -0,0 +1,87 @@
+package B_Tree
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// TestBTree_OrderStatistics сравнивает Rank, Select и CountRange с отсортированным срезом
+// на случайных вставках и удалениях, включая повторяющиеся ключи
+func TestBTree_OrderStatistics(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, order := range []int{2, 3, 5} {
+		tree := NewBTree[int](order)
+		var model []int
+
+		for op := 0; op < 3000; op++ {
+			k := rng.Intn(300)
+			if rng.Intn(3) == 0 {
+				tree.Delete(k)
+				if i, found := slices.BinarySearch(model, k); found {
+					model = slices.Delete(model, i, i+1)
+				}
+			} else {
+				tree.Insert(k)
+				i, _ := slices.BinarySearch(model, k)
+				model = slices.Insert(model, i, k)
+			}
+
+			if tree.Len() != len(model) {
+				t.Fatalf("t=%d op %d: Len %d, want %d", order, op, tree.Len(), len(model))
+			}
+			if op%50 != 0 {
+				continue
+			}
+			checkInvariants(t, tree.Root, order)
+
+			for i, want := range model {
+				if got, ok := tree.Select(i); !ok || got != want {
+					t.Fatalf("t=%d: Select(%d) = (%d, %v), want %d", order, i, got, ok, want)
+				}
+			}
+			if _, ok := tree.Select(len(model)); ok {
+				t.Fatalf("t=%d: Select(Len()) reported a key", order)
+			}
+			for q := -1; q <= 301; q += 7 {
+				if got, want := tree.Rank(q), sort.SearchInts(model, q); got != want {
+					t.Fatalf("t=%d: Rank(%d) = %d, want %d", order, q, got, want)
+				}
+			}
+			for i := 0; i < 20; i++ {
+				lo, hi := rng.Intn(310)-5, rng.Intn(310)-5
+				for _, bounds := range []Bounds{Closed, ClosedOpen, OpenClosed, Open} {
+					want := 0
+					for _, k := range model {
+						if (k > lo || bounds.LoInclusive() && k == lo) && (k < hi || bounds.HiInclusive() && k == hi) {
+							want++
+						}
+					}
+					if got := tree.CountRange(lo, hi, bounds); got != want {
+						t.Fatalf("t=%d: CountRange(%d, %d, %d) = %d, want %d", order, lo, hi, bounds, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBTreeMap_Select(t *testing.T) {
+	m := NewBTreeMap[string, int](2)
+	for i, k := range []string{"d", "b", "a", "c", "e"} {
+		m.Put(k, i)
+	}
+	m.Put("c", 100)
+	m.Delete("a")
+
+	if k, v, ok := m.Select(1); !ok || k != "c" || v != 100 {
+		t.Errorf("Select(1) = (%q, %d, %v), want (c, 100)", k, v, ok)
+	}
+	if r := m.Rank("d"); r != 2 {
+		t.Errorf("Rank(d) = %d, want 2", r)
+	}
+	if n := m.CountRange("b", "d", ClosedOpen); n != 2 {
+		t.Errorf("CountRange[b, d) = %d, want 2", n)
+	}
+}
@@ -0,0 +1,594 @@
+package BPlus_Tree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// BPlusTree – B+-дерево, хранящееся в страничном файле; по операциям повторяет B_Tree.BTree,
+// но каждая операция, обращающаяся к файлу, дополнительно возвращает ошибку.
+// Повторяющиеся ключи хранятся столько раз, сколько вставлены.
+// Изменения гарантированно сохраняются только после завершённого Sync или Close. Журнала нет:
+// вытесняемые из пула изменённые страницы перезаписываются в файле на месте, поэтому любой
+// сбой до завершения Sync может оставить файл повреждённым, а не в прежнем состоянии.
+// Дерево не потокобезопасно.
+type BPlusTree[T constraints.Ordered] struct {
+	tree *diskTree
+}
+
+// diskTree – B+-дерево уникальных байтовых записей, общее для BPlusTree и BPlusTreeMap.
+// Узлы фиксированного размера читаются через буферный пул, поэтому в памяти находится
+// не больше Options.PoolPages страниц. Записи хранятся только в листьях, листья связаны
+// в двусвязный список для последовательного обхода. Записи длиннее Options.InlineKey
+// продолжаются в цепочке страниц.
+type diskTree struct {
+	p           *pager
+	leafCap     int // наибольшее число записей в листе
+	internalCap int // наибольшее число записей во внутреннем узле
+}
+
+// Options задаёт параметры файла дерева. Нулевые поля заменяются значениями по умолчанию.
+// PageSize и InlineKey записываются в файл и при повторном открытии должны совпадать.
+type Options struct {
+	PageSize  int // размер страницы в байтах, 256..65536; по умолчанию 4096
+	PoolPages int // число страниц в буферном пуле, >= 8; по умолчанию 256
+	InlineKey int // сколько байт ключа хранится в самом узле; по умолчанию 48
+}
+
+const (
+	defaultPageSize  = 4096
+	defaultPoolPages = 256
+	defaultInlineKey = 48
+)
+
+// ErrInvalidOptions – недопустимые параметры Options.
+var ErrInvalidOptions = errors.New("invalid B+Tree options")
+
+func (o *Options) normalize() error {
+	if o.PageSize == 0 {
+		o.PageSize = defaultPageSize
+	}
+	if o.PoolPages == 0 {
+		o.PoolPages = defaultPoolPages
+	}
+	if o.InlineKey == 0 {
+		o.InlineKey = defaultInlineKey
+	}
+	if o.PageSize < 256 || o.PageSize > 1<<16 {
+		return fmt.Errorf("%w: page size %d is out of range 256..65536", ErrInvalidOptions, o.PageSize)
+	}
+	// Пул должен вмещать страницы, которые одна операция читает повторно.
+	if o.PoolPages < 8 {
+		return fmt.Errorf("%w: pool must hold at least 8 pages, got %d", ErrInvalidOptions, o.PoolPages)
+	}
+	if o.InlineKey < 1 {
+		return fmt.Errorf("%w: inline key size must be positive, got %d", ErrInvalidOptions, o.InlineKey)
+	}
+	return nil
+}
+
+// Open открывает файл дерева или создаёт пустое дерево, если файла нет или он пуст.
+func Open[T constraints.Ordered](path string, opts Options) (*BPlusTree[T], error) {
+	tree, err := openDiskTree(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BPlusTree[T]{tree: tree}, nil
+}
+
+func openDiskTree(path string, opts Options) (*diskTree, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	slot := 8 + opts.InlineKey
+	t := &diskTree{
+		leafCap:     (opts.PageSize - nodeHeader) / slot,
+		internalCap: (opts.PageSize - nodeHeader - childEntry) / (slot + childEntry),
+	}
+	if t.internalCap < 3 {
+		return nil, fmt.Errorf("%w: inline key %d leaves room for only %d keys in a %d-byte page",
+			ErrInvalidOptions, opts.InlineKey, t.internalCap, opts.PageSize)
+	}
+
+	p, err := openPager(path, opts.PageSize, opts.PoolPages, opts.InlineKey)
+	if err != nil {
+		return nil, err
+	}
+	t.p = p
+	if p.root == 0 {
+		root, err := t.newNode(true)
+		if err == nil {
+			err = t.writeNode(root)
+		}
+		if err == nil {
+			p.root = root.id
+			err = p.flush()
+		}
+		if err != nil {
+			p.file.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Sync записывает все изменения в файл и дожидается их сохранения на диске.
+// Журнала нет: сбой во время Sync может повредить файл.
+func (t *BPlusTree[T]) Sync() error {
+	return t.tree.sync()
+}
+
+// Close сохраняет изменения и закрывает файл. После Close дерево использовать нельзя.
+func (t *BPlusTree[T]) Close() error {
+	return t.tree.close()
+}
+
+// Len возвращает число ключей.
+func (t *BPlusTree[T]) Len() int {
+	return t.tree.len()
+}
+
+func (t *diskTree) sync() error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	return t.p.flush()
+}
+
+func (t *diskTree) close() error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	err := t.p.close()
+	t.p = nil
+	return err
+}
+
+func (t *diskTree) len() int {
+	if t.p == nil {
+		return 0
+	}
+	return int(t.p.count)
+}
+
+// minKeys возвращает наименьшее допустимое число ключей в некорневом узле.
+func (t *diskTree) minKeys(n *node) int {
+	if n.leaf {
+		return t.leafCap / 2
+	}
+	return t.internalCap / 2
+}
+
+// ---------- ВХОЖДЕНИЯ КЛЮЧЕЙ ----------
+
+// Вхождения ключа k – записи из кодировки k и 8-байтового номера вхождения (big-endian),
+// поэтому они уникальны и идут подряд в порядке вставки.
+
+// Insert добавляет ключ k. Повторяющиеся ключи хранятся столько раз, сколько вставлены.
+func (t *BPlusTree[T]) Insert(k T) error {
+	s := spanOf(k)
+	// Новое вхождение получает номер на единицу больше последнего.
+	last, found, err := first(t.tree.downFrom(s, true))
+	if err != nil {
+		return err
+	}
+	var seq uint64
+	if found && s.holds(last) {
+		if len(last) != len(s.lo)+8 {
+			return fmt.Errorf("%w: entry of %d bytes is not a key occurrence", ErrCorrupted, len(last))
+		}
+		seq = binary.BigEndian.Uint64([]byte(last[len(s.lo):])) + 1
+	}
+	_, err = t.tree.insertEntry(s.lo + string(binary.BigEndian.AppendUint64(nil, seq)))
+	return err
+}
+
+// Delete удаляет одно вхождение ключа k. Возвращает false, если ключа не было.
+func (t *BPlusTree[T]) Delete(k T) (bool, error) {
+	e, found, err := t.tree.entryOf(spanOf(k))
+	if err != nil || !found {
+		return false, err
+	}
+	return t.tree.deleteEntry(e)
+}
+
+// Search сообщает, есть ли ключ k в дереве.
+func (t *BPlusTree[T]) Search(k T) (bool, error) {
+	_, found, err := t.tree.entryOf(spanOf(k))
+	return found, err
+}
+
+// entryOf возвращает первую запись ключа s; false, если записей ключа нет.
+func (t *diskTree) entryOf(s span) (string, bool, error) {
+	e, found, err := first(t.upFrom(s, false))
+	if err != nil || !found || !s.holds(e) {
+		return "", false, err
+	}
+	return e, true, nil
+}
+
+// ---------- ПОИСК ----------
+
+// readNodeAt читает узел id, лежащий на глубине depth (у корня – 0). Путь от корня
+// к листу не длиннее числа страниц файла, поэтому более глубокий узел означает
+// цикл в ссылках на детей.
+func (t *diskTree) readNodeAt(id uint32, depth int) (*node, error) {
+	if depth >= int(t.p.pages) {
+		return nil, fmt.Errorf("%w: page %d is deeper than the file allows, child links form a cycle", ErrCorrupted, id)
+	}
+	return t.readNode(id)
+}
+
+// findLeaf спускается к листу, который может содержать k. Если k == nil, возвращается
+// самый правый лист при last и самый левый иначе.
+func (t *diskTree) findLeaf(k *string, last bool) (*node, error) {
+	n, err := t.readNode(t.p.root)
+	for depth := 1; err == nil && !n.leaf; depth++ {
+		i := 0
+		switch {
+		case k != nil:
+			// Разделитель равен наименьшему ключу правого поддерева.
+			i, err = t.search(n, *k, true)
+		case last:
+			i = len(n.children) - 1
+		}
+		if err == nil {
+			n, err = t.readNodeAt(n.children[i], depth)
+		}
+	}
+	return n, err
+}
+
+// ---------- ВСТАВКА ----------
+
+// split описывает разделение узла: новый правый узел right и разделитель sep между ними.
+type split struct {
+	sep       key
+	right     uint32
+	leftSize  int
+	rightSize int
+}
+
+// insertEntry добавляет запись k. Возвращает false, если запись уже была.
+func (t *diskTree) insertEntry(k string) (bool, error) {
+	if t.p == nil {
+		return false, ErrClosed
+	}
+	inserted, sp, err := t.insert(t.p.root, k, 0)
+	if err != nil {
+		return false, err
+	}
+	if sp != nil {
+		root, err := t.newNode(false)
+		if err != nil {
+			return false, err
+		}
+		root.keys = []key{sp.sep}
+		root.children = []uint32{t.p.root, sp.right}
+		root.counts = []uint32{uint32(sp.leftSize), uint32(sp.rightSize)}
+		if err := t.writeNode(root); err != nil {
+			return false, err
+		}
+		t.p.root = root.id
+	}
+	if inserted {
+		t.p.count++
+	}
+	return inserted, nil
+}
+
+func (t *diskTree) insert(id uint32, k string, depth int) (bool, *split, error) {
+	n, err := t.readNodeAt(id, depth)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if n.leaf {
+		i, err := t.search(n, k, false)
+		if err != nil {
+			return false, nil, err
+		}
+		if i < len(n.keys) {
+			if c, err := t.compare(k, n.keys[i]); err != nil || c == 0 {
+				return false, nil, err
+			}
+		}
+		slot, err := t.makeKey(k)
+		if err != nil {
+			return false, nil, err
+		}
+		n.keys = slices.Insert(n.keys, i, slot)
+		if len(n.keys) <= t.leafCap {
+			return true, nil, t.writeNode(n)
+		}
+		sp, err := t.splitLeaf(n)
+		return true, sp, err
+	}
+
+	i, err := t.search(n, k, true)
+	if err != nil {
+		return false, nil, err
+	}
+	inserted, sp, err := t.insert(n.children[i], k, depth+1)
+	if err != nil || !inserted {
+		return false, nil, err
+	}
+	n.counts[i]++
+	if sp != nil {
+		n.counts[i] = uint32(sp.leftSize)
+		n.keys = slices.Insert(n.keys, i, sp.sep)
+		n.children = slices.Insert(n.children, i+1, sp.right)
+		n.counts = slices.Insert(n.counts, i+1, uint32(sp.rightSize))
+	}
+	if len(n.keys) <= t.internalCap {
+		return true, nil, t.writeNode(n)
+	}
+	sp, err = t.splitInternal(n)
+	return true, sp, err
+}
+
+// splitLeaf переносит вторую половину ключей переполненного листа в новый лист.
+// Разделителем становится копия наименьшего ключа правого листа.
+func (t *diskTree) splitLeaf(n *node) (*split, error) {
+	right, err := t.newNode(true)
+	if err != nil {
+		return nil, err
+	}
+	mid := len(n.keys) / 2
+	right.keys = slices.Clone(n.keys[mid:])
+	n.keys = n.keys[:mid]
+
+	right.prev, right.next = n.id, n.next
+	if n.next != 0 {
+		next, err := t.readNode(n.next)
+		if err != nil {
+			return nil, err
+		}
+		next.prev = right.id
+		if err := t.writeNode(next); err != nil {
+			return nil, err
+		}
+	}
+	n.next = right.id
+
+	sep, err := t.copyKey(right.keys[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := t.writeNode(n); err != nil {
+		return nil, err
+	}
+	if err := t.writeNode(right); err != nil {
+		return nil, err
+	}
+	return &split{sep: sep, right: right.id, leftSize: len(n.keys), rightSize: len(right.keys)}, nil
+}
+
+// splitInternal делит переполненный внутренний узел; средний ключ поднимается в родителя.
+func (t *diskTree) splitInternal(n *node) (*split, error) {
+	right, err := t.newNode(false)
+	if err != nil {
+		return nil, err
+	}
+	mid := len(n.keys) / 2
+	sep := n.keys[mid]
+	right.keys = slices.Clone(n.keys[mid+1:])
+	right.children = slices.Clone(n.children[mid+1:])
+	right.counts = slices.Clone(n.counts[mid+1:])
+	n.keys = n.keys[:mid]
+	n.children = n.children[:mid+1]
+	n.counts = n.counts[:mid+1]
+
+	if err := t.writeNode(n); err != nil {
+		return nil, err
+	}
+	if err := t.writeNode(right); err != nil {
+		return nil, err
+	}
+	return &split{sep: sep, right: right.id, leftSize: n.size(), rightSize: right.size()}, nil
+}
+
+// ---------- УДАЛЕНИЕ ----------
+
+// deleteEntry удаляет запись k. Возвращает false, если записи не было.
+func (t *diskTree) deleteEntry(k string) (bool, error) {
+	if t.p == nil {
+		return false, ErrClosed
+	}
+	found, err := t.remove(t.p.root, k, 0)
+	if err != nil || !found {
+		return false, err
+	}
+	t.p.count--
+
+	// Корень без ключей заменяется единственным ребёнком.
+	root, err := t.readNode(t.p.root)
+	if err != nil {
+		return true, err
+	}
+	if !root.leaf && len(root.keys) == 0 {
+		t.p.root = root.children[0]
+		if err := t.p.free(root.id); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (t *diskTree) remove(id uint32, k string, depth int) (bool, error) {
+	n, err := t.readNodeAt(id, depth)
+	if err != nil {
+		return false, err
+	}
+
+	if n.leaf {
+		i, err := t.search(n, k, false)
+		if err != nil || i == len(n.keys) {
+			return false, err
+		}
+		if c, err := t.compare(k, n.keys[i]); err != nil || c != 0 {
+			return false, err
+		}
+		if err := t.freeKey(n.keys[i]); err != nil {
+			return false, err
+		}
+		n.keys = slices.Delete(n.keys, i, i+1)
+		return true, t.writeNode(n)
+	}
+
+	// Разделители, равные удалённому ключу, остаются: они по-прежнему разделяют поддеревья.
+	i, err := t.search(n, k, true)
+	if err != nil {
+		return false, err
+	}
+	found, err := t.remove(n.children[i], k, depth+1)
+	if err != nil || !found {
+		return false, err
+	}
+	n.counts[i]--
+	child, err := t.readNode(n.children[i])
+	if err != nil {
+		return false, err
+	}
+	if len(child.keys) < t.minKeys(child) {
+		if err := t.rebalance(n, i, child); err != nil {
+			return false, err
+		}
+	}
+	return true, t.writeNode(n)
+}
+
+// rebalance восполняет недостаток ключей в ребёнке c узла p с индексом i: занимает ключ
+// у соседа или сливает c с соседом. Узел p изменяется, но не записывается.
+func (t *diskTree) rebalance(p *node, i int, c *node) error {
+	var left, right *node
+	var err error
+	if i > 0 {
+		if left, err = t.readNode(p.children[i-1]); err != nil {
+			return err
+		}
+		if len(left.keys) > t.minKeys(left) {
+			return t.borrowFromLeft(p, i, left, c)
+		}
+	}
+	if i+1 < len(p.children) {
+		if right, err = t.readNode(p.children[i+1]); err != nil {
+			return err
+		}
+		if len(right.keys) > t.minKeys(right) {
+			return t.borrowFromRight(p, i, c, right)
+		}
+	}
+	if left != nil {
+		return t.merge(p, i-1, left, c)
+	}
+	return t.merge(p, i, c, right)
+}
+
+// borrowFromLeft переносит последний ключ левого соседа left в начало ребёнка c.
+func (t *diskTree) borrowFromLeft(p *node, i int, left, c *node) error {
+	last := len(left.keys) - 1
+	if c.leaf {
+		c.keys = slices.Insert(c.keys, 0, left.keys[last])
+		left.keys = left.keys[:last]
+		if err := t.replaceSeparator(p, i-1, c.keys[0]); err != nil {
+			return err
+		}
+		p.counts[i-1]--
+		p.counts[i]++
+	} else {
+		moved := left.counts[last+1]
+		c.keys = slices.Insert(c.keys, 0, p.keys[i-1])
+		c.children = slices.Insert(c.children, 0, left.children[last+1])
+		c.counts = slices.Insert(c.counts, 0, moved)
+		p.keys[i-1] = left.keys[last]
+		left.keys = left.keys[:last]
+		left.children = left.children[:last+1]
+		left.counts = left.counts[:last+1]
+		p.counts[i-1] -= moved
+		p.counts[i] += moved
+	}
+	if err := t.writeNode(left); err != nil {
+		return err
+	}
+	return t.writeNode(c)
+}
+
+// borrowFromRight переносит первый ключ правого соседа right в конец ребёнка c.
+func (t *diskTree) borrowFromRight(p *node, i int, c, right *node) error {
+	if c.leaf {
+		c.keys = append(c.keys, right.keys[0])
+		right.keys = slices.Delete(right.keys, 0, 1)
+		if err := t.replaceSeparator(p, i, right.keys[0]); err != nil {
+			return err
+		}
+		p.counts[i]++
+		p.counts[i+1]--
+	} else {
+		moved := right.counts[0]
+		c.keys = append(c.keys, p.keys[i])
+		c.children = append(c.children, right.children[0])
+		c.counts = append(c.counts, moved)
+		p.keys[i] = right.keys[0]
+		right.keys = slices.Delete(right.keys, 0, 1)
+		right.children = slices.Delete(right.children, 0, 1)
+		right.counts = slices.Delete(right.counts, 0, 1)
+		p.counts[i] += moved
+		p.counts[i+1] -= moved
+	}
+	if err := t.writeNode(c); err != nil {
+		return err
+	}
+	return t.writeNode(right)
+}
+
+// merge сливает ребёнка j+1 (right) в ребёнка j (left) и освобождает страницу right.
+func (t *diskTree) merge(p *node, j int, left, right *node) error {
+	if left.leaf {
+		left.keys = append(left.keys, right.keys...)
+		left.next = right.next
+		if right.next != 0 {
+			next, err := t.readNode(right.next)
+			if err != nil {
+				return err
+			}
+			next.prev = left.id
+			if err := t.writeNode(next); err != nil {
+				return err
+			}
+		}
+		// Разделитель листьев – копия ключа, она больше не нужна.
+		if err := t.freeKey(p.keys[j]); err != nil {
+			return err
+		}
+	} else {
+		left.keys = append(append(left.keys, p.keys[j]), right.keys...)
+		left.children = append(left.children, right.children...)
+		left.counts = append(left.counts, right.counts...)
+	}
+	p.counts[j] += p.counts[j+1]
+	p.keys = slices.Delete(p.keys, j, j+1)
+	p.children = slices.Delete(p.children, j+1, j+2)
+	p.counts = slices.Delete(p.counts, j+1, j+2)
+	if err := t.writeNode(left); err != nil {
+		return err
+	}
+	return t.p.free(right.id)
+}
+
+// replaceSeparator заменяет разделитель p.keys[i] копией ключа k.
+func (t *diskTree) replaceSeparator(p *node, i int, k key) error {
+	sep, err := t.copyKey(k)
+	if err != nil {
+		return err
+	}
+	if err := t.freeKey(p.keys[i]); err != nil {
+		return err
+	}
+	p.keys[i] = sep
+	return nil
+}
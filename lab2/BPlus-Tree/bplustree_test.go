@@ -0,0 +1,557 @@
+package BPlus_Tree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	B_Tree "btree-example/B-Tree"
+)
+
+// smallOptions даёт маленькие страницы и пул, чтобы дерево было глубоким, страницы
+// вытеснялись, а длинные ключи занимали по несколько страниц продолжения.
+var smallOptions = Options{PageSize: 256, PoolPages: 8, InlineKey: 16}
+
+func openTree(tb testing.TB, path string, opts Options) *BPlusTree[string] {
+	tb.Helper()
+	tree, err := Open[string](path, opts)
+	if err != nil {
+		tb.Fatalf("Open: %v", err)
+	}
+	return tree
+}
+
+// testKey возвращает i-й ключ. Каждый третий ключ длинный и совпадает с соседними
+// во встроенной части, поэтому сравнение читает страницы продолжения.
+func testKey(i int) string {
+	if i%3 == 0 {
+		return fmt.Sprintf("long/%s/%04d/%s", strings.Repeat("p", 20), i, strings.Repeat("s", i%600))
+	}
+	return fmt.Sprintf("key/%04d", i)
+}
+
+func collect(tb testing.TB, seq func(yield func(string, error) bool)) []string {
+	tb.Helper()
+	var keys []string
+	for k, err := range seq {
+		if err != nil {
+			tb.Fatalf("iteration failed: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// checkInvariants проверяет упорядоченность ключей и разделителей, заполненность узлов,
+// одинаковую глубину листьев, числа ключей в поддеревьях, связи между листьями и то,
+// что каждая страница файла принадлежит ровно одному узлу, ключу или списку свободных.
+func checkInvariants(tb testing.TB, d *diskTree) {
+	tb.Helper()
+	owner := make([]string, d.p.pages)
+	owner[0] = "meta"
+	claim := func(id uint32, what string) {
+		if id == 0 || id >= d.p.pages {
+			tb.Fatalf("%s refers to page %d out of range", what, id)
+		}
+		if owner[id] != "" {
+			tb.Fatalf("page %d is used by both %s and %s", id, owner[id], what)
+		}
+		owner[id] = what
+	}
+	claimKey := func(k key, what string) {
+		left := k.length - len(k.prefix)
+		for id := k.overflow; left > 0; left -= d.p.pageSize - overflowHeader {
+			claim(id, what)
+			data, err := d.p.page(id)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			id = binary.LittleEndian.Uint32(data[4:])
+		}
+	}
+	load := func(k key) string {
+		s, err := d.loadKey(k)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return s
+	}
+
+	var leaves []uint32
+	leafDepth := -1
+	var walk func(id uint32, lo, hi *string, depth int) int
+	walk = func(id uint32, lo, hi *string, depth int) int {
+		claim(id, fmt.Sprintf("node %d", id))
+		n, err := d.readNode(id)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if id != d.p.root && len(n.keys) < d.minKeys(n) {
+			tb.Fatalf("node %d has %d keys, minimum is %d", id, len(n.keys), d.minKeys(n))
+		}
+		keys := make([]string, len(n.keys))
+		for i, k := range n.keys {
+			claimKey(k, fmt.Sprintf("key %d of node %d", i, id))
+			keys[i] = load(k)
+			if i > 0 && keys[i-1] >= keys[i] {
+				tb.Fatalf("node %d keys out of order: %q >= %q", id, keys[i-1], keys[i])
+			}
+			if lo != nil && keys[i] < *lo || hi != nil && keys[i] >= *hi {
+				tb.Fatalf("node %d key %q is outside its separators", id, keys[i])
+			}
+		}
+		if n.leaf {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				tb.Fatalf("leaf %d at depth %d, other leaves at %d", id, depth, leafDepth)
+			}
+			leaves = append(leaves, id)
+			return len(n.keys)
+		}
+		if len(n.children) != len(n.keys)+1 {
+			tb.Fatalf("node %d has %d keys and %d children", id, len(n.keys), len(n.children))
+		}
+		total := 0
+		for i, child := range n.children {
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &keys[i-1]
+			}
+			if i < len(keys) {
+				childHi = &keys[i]
+			}
+			size := walk(child, childLo, childHi, depth+1)
+			if int(n.counts[i]) != size {
+				tb.Fatalf("node %d stores count %d for child %d, subtree has %d keys", id, n.counts[i], child, size)
+			}
+			total += size
+		}
+		return total
+	}
+	if size := walk(d.p.root, nil, nil, 0); size != d.len() {
+		tb.Fatalf("tree has %d entries, len() = %d", size, d.len())
+	}
+
+	for i, id := range leaves {
+		n, err := d.readNode(id)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		var prev, next uint32
+		if i > 0 {
+			prev = leaves[i-1]
+		}
+		if i+1 < len(leaves) {
+			next = leaves[i+1]
+		}
+		if n.prev != prev || n.next != next {
+			tb.Fatalf("leaf %d links (%d, %d), want (%d, %d)", id, n.prev, n.next, prev, next)
+		}
+	}
+
+	for id := d.p.freeHead; id != 0; {
+		claim(id, "free list")
+		data, err := d.p.page(id)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		id = binary.LittleEndian.Uint32(data[4:])
+	}
+	for id, what := range owner {
+		if what == "" {
+			tb.Fatalf("page %d is leaked", id)
+		}
+	}
+}
+
+// TestBPlusTree_RandomOperations сравнивает дерево с отсортированным срезом на случайных
+// вставках и удалениях коротких и длинных ключей, в том числе повторяющихся
+func TestBPlusTree_RandomOperations(t *testing.T) {
+	for _, opts := range []Options{smallOptions, {PageSize: 512, PoolPages: 16, InlineKey: 8}, {}} {
+		t.Run(fmt.Sprintf("page-%d", opts.PageSize), func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), opts)
+			defer tree.Close()
+			var model []string
+
+			for op := 0; op < 3000; op++ {
+				k := testKey(rng.Intn(400))
+				i, found := slices.BinarySearch(model, k)
+				if rng.Intn(3) == 0 {
+					deleted, err := tree.Delete(k)
+					if err != nil {
+						t.Fatalf("Delete(%q): %v", k, err)
+					}
+					if deleted != found {
+						t.Fatalf("Delete(%q) = %v, key present: %v", k, deleted, found)
+					}
+					if found {
+						model = slices.Delete(model, i, i+1)
+					}
+				} else {
+					if err := tree.Insert(k); err != nil {
+						t.Fatalf("Insert(%q): %v", k, err)
+					}
+					model = slices.Insert(model, i, k)
+				}
+
+				if tree.Len() != len(model) {
+					t.Fatalf("op %d: Len %d, want %d", op, tree.Len(), len(model))
+				}
+				if op%100 != 0 {
+					continue
+				}
+				checkInvariants(t, tree.tree)
+				if got := collect(t, tree.All()); !slices.Equal(got, model) {
+					t.Fatalf("op %d: All() differs from model: %d keys, want %d", op, len(got), len(model))
+				}
+				for q := 0; q < 400; q += 13 {
+					k := testKey(q)
+					_, want := slices.BinarySearch(model, k)
+					if got, err := tree.Search(k); err != nil || got != want {
+						t.Fatalf("Search(%q) = (%v, %v), want %v", k, got, err, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestBPlusTree_Duplicates проверяет, что повторяющиеся ключи хранятся столько раз,
+// сколько вставлены, и удаляются по одному, как в B_Tree.BTree
+func TestBPlusTree_Duplicates(t *testing.T) {
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+	for _, k := range []string{"b", "a", "b", "c", "b", "a"} {
+		if err := tree.Insert(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := collect(t, tree.All()), []string{"a", "a", "b", "b", "b", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("All = %v, want %v", got, want)
+	}
+	if n, err := tree.CountRange("b", "b", B_Tree.Closed); err != nil || n != 3 {
+		t.Errorf("CountRange(b, b) = (%d, %v), want 3", n, err)
+	}
+	if r, err := tree.Rank("c"); err != nil || r != 5 {
+		t.Errorf("Rank(c) = (%d, %v), want 5", r, err)
+	}
+	if k, _, err := tree.Successor("a"); err != nil || k != "b" {
+		t.Errorf("Successor(a) = (%q, %v), want b", k, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if deleted, err := tree.Delete("b"); err != nil || !deleted {
+			t.Fatalf("Delete(b) #%d = (%v, %v)", i, deleted, err)
+		}
+	}
+	if deleted, err := tree.Delete("b"); err != nil || deleted {
+		t.Errorf("Delete of the last removed key = (%v, %v), want false", deleted, err)
+	}
+	if got, want := collect(t, tree.All()), []string{"a", "a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("All after deletes = %v, want %v", got, want)
+	}
+	checkInvariants(t, tree.tree)
+}
+
+// TestBPlusTree_DeleteAll проверяет, что после удаления всех ключей дерево пусто,
+// а освободившиеся страницы используются повторно
+func TestBPlusTree_DeleteAll(t *testing.T) {
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+
+	perm := rand.New(rand.NewSource(2)).Perm(500)
+	for _, i := range perm {
+		if err := tree.Insert(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pages := tree.tree.p.pages
+	for _, i := range perm[:250] {
+		if _, err := tree.Delete(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkInvariants(t, tree.tree)
+	for _, i := range slices.Backward(perm) {
+		if _, err := tree.Delete(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkInvariants(t, tree.tree)
+	if tree.Len() != 0 {
+		t.Fatalf("Len after deleting everything = %d", tree.Len())
+	}
+	if keys := collect(t, tree.All()); len(keys) != 0 {
+		t.Fatalf("All() after deleting everything returned %d keys", len(keys))
+	}
+
+	for _, i := range perm {
+		if err := tree.Insert(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.tree.p.pages != pages {
+		t.Errorf("file grew from %d to %d pages instead of reusing freed pages", pages, tree.tree.p.pages)
+	}
+}
+
+// TestBPlusTree_Reopen проверяет, что ключи сохраняются после Close и повторного Open
+func TestBPlusTree_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTree(t, path, smallOptions)
+	var model []string
+	for i := 0; i < 300; i++ {
+		if err := tree.Insert(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 300; i += 4 {
+		if _, err := tree.Delete(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 300; i++ {
+		if i%4 != 0 {
+			model = append(model, testKey(i))
+		}
+	}
+	slices.Sort(model)
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := tree.Insert("x"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Insert after Close returned %v, want ErrClosed", err)
+	}
+
+	tree = openTree(t, path, smallOptions)
+	defer tree.Close()
+	checkInvariants(t, tree.tree)
+	if tree.Len() != len(model) {
+		t.Fatalf("Len after reopen = %d, want %d", tree.Len(), len(model))
+	}
+	if got := collect(t, tree.All()); !slices.Equal(got, model) {
+		t.Fatalf("keys after reopen differ from model")
+	}
+}
+
+// TestBPlusTree_Sync проверяет, что после Sync файл содержит все изменения,
+// даже если дерево так и не было закрыто
+func TestBPlusTree_Sync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	tree := openTree(t, path, smallOptions)
+	defer tree.Close()
+	for i := 0; i < 200; i++ {
+		if err := tree.Insert(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	// Копия файла открывается независимо от несброшенного состояния дерева.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyPath := filepath.Join(t.TempDir(), "copy.db")
+	if err := os.WriteFile(copyPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := openTree(t, copyPath, smallOptions)
+	defer snapshot.Close()
+	checkInvariants(t, snapshot.tree)
+	if snapshot.Len() != 200 {
+		t.Fatalf("synced file has %d keys, want 200", snapshot.Len())
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, opts := range map[string]Options{
+		"small page":   {PageSize: 128},
+		"small pool":   {PoolPages: 2},
+		"inline key":   {PageSize: 256, InlineKey: 200},
+		"negative key": {InlineKey: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Open[string](filepath.Join(dir, "invalid.db"), opts); !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("Open returned %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+
+	path := filepath.Join(dir, "tree.db")
+	tree := openTree(t, path, smallOptions)
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open[string](path, Options{PageSize: 512, InlineKey: 16}); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Open with another page size returned %v, want ErrIncompatible", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte("not a tree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open[string](garbage, Options{}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Open of a foreign file returned %v, want ErrCorrupted", err)
+	}
+}
+
+// patchPage изменяет страницу id файла закрытого дерева со страницами smallOptions.
+func patchPage(tb testing.TB, path string, id uint32, patch func(data []byte)) {
+	tb.Helper()
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+	data := make([]byte, smallOptions.PageSize)
+	offset := int64(id) * int64(smallOptions.PageSize)
+	if _, err := file.ReadAt(data, offset); err != nil {
+		tb.Fatal(err)
+	}
+	patch(data)
+	if _, err := file.WriteAt(data, offset); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// corruptTree создаёт файл с ключами keys и возвращает путь к нему и номер корня.
+func corruptTree(tb testing.TB, keys []string) (string, uint32) {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "tree.db")
+	tree := openTree(tb, path, smallOptions)
+	for _, k := range keys {
+		if err := tree.Insert(k); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	root := tree.tree.p.root
+	if err := tree.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return path, root
+}
+
+// TestBPlusTree_Corrupted проверяет, что повреждённые ссылки и длины ключей дают
+// ErrCorrupted, а не зацикливание, огромное выделение памяти или порчу списка свободных
+func TestBPlusTree_Corrupted(t *testing.T) {
+	var many []string
+	for i := 0; i < 300; i++ {
+		many = append(many, testKey(i))
+	}
+	long := "long/" + strings.Repeat("x", 600)
+	expect := func(t *testing.T, what string, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s returned %v, want ErrCorrupted", what, err)
+		}
+	}
+	firstErr := func(seq func(yield func(string, error) bool)) error {
+		for _, err := range seq {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("child cycle", func(t *testing.T) {
+		path, root := corruptTree(t, many)
+		// Первый ребёнок корня указывает на сам корень.
+		patchPage(t, path, root, func(data []byte) {
+			binary.LittleEndian.PutUint32(data[nodeHeader:], root)
+		})
+		tree := openTree(t, path, smallOptions)
+		defer tree.Close()
+		_, _, err := tree.Min()
+		expect(t, "Min", err)
+		_, err = tree.Rank("")
+		expect(t, "Rank", err)
+		_, _, err = tree.Select(0)
+		expect(t, "Select", err)
+		err = tree.Insert("")
+		expect(t, "Insert", err)
+		_, err = tree.Delete("")
+		expect(t, "Delete", err)
+	})
+
+	t.Run("leaf cycle", func(t *testing.T) {
+		path, root := corruptTree(t, many)
+		tree := openTree(t, path, smallOptions)
+		n, err := tree.tree.readNode(root)
+		for err == nil && !n.leaf {
+			n, err = tree.tree.readNode(n.children[0])
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Самый левый лист ссылается на себя как на следующий.
+		patchPage(t, path, n.id, func(data []byte) {
+			binary.LittleEndian.PutUint32(data[8:], n.id)
+		})
+		tree = openTree(t, path, smallOptions)
+		defer tree.Close()
+		expect(t, "All", firstErr(tree.All()))
+	})
+
+	t.Run("key length", func(t *testing.T) {
+		path, root := corruptTree(t, []string{long})
+		patchPage(t, path, root, func(data []byte) {
+			binary.LittleEndian.PutUint32(data[nodeHeader:], 0xfffffff0)
+		})
+		tree := openTree(t, path, smallOptions)
+		defer tree.Close()
+		expect(t, "All", firstErr(tree.All()))
+		_, err := tree.Delete(long)
+		expect(t, "Delete", err)
+	})
+
+	t.Run("overflow page type", func(t *testing.T) {
+		path, root := corruptTree(t, []string{long})
+		// Продолжение ключа указывает на страницу листа.
+		patchPage(t, path, root, func(data []byte) {
+			binary.LittleEndian.PutUint32(data[nodeHeader+4:], root)
+		})
+		tree := openTree(t, path, smallOptions)
+		defer tree.Close()
+		expect(t, "All", firstErr(tree.All()))
+		n, err := tree.tree.readNode(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect(t, "freeKey", tree.tree.freeKey(n.keys[0]))
+		if tree.tree.p.freeHead != 0 {
+			t.Errorf("freeKey put page %d into the free list", tree.tree.p.freeHead)
+		}
+	})
+}
+
+func BenchmarkBPlusTree_Insert(b *testing.B) {
+	for _, pool := range []int{16, 1024} {
+		b.Run(fmt.Sprintf("pool-%d", pool), func(b *testing.B) {
+			tree := openTree(b, filepath.Join(b.TempDir(), "tree.db"), Options{PoolPages: pool})
+			defer tree.Close()
+			rng := rand.New(rand.NewSource(1))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := tree.Insert(fmt.Sprintf("%016x", rng.Uint64())); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
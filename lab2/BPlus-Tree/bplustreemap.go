@@ -0,0 +1,100 @@
+package BPlus_Tree
+
+import "golang.org/x/exp/constraints"
+
+// BPlusTreeMap – упорядоченное отображение ключей в значения на том же файловом B+-дереве,
+// что и BPlusTree; по операциям повторяет B_Tree.BTreeMap. Ключи уникальны: Put существующего
+// ключа заменяет его значение. Значения переводятся в байты кодеком values.
+// Как и у BPlusTree, журнала нет: изменённые страницы при вытеснении из пула перезаписываются
+// на месте, и любой сбой до завершения Sync или Close может оставить файл повреждённым.
+type BPlusTreeMap[K constraints.Ordered, V any] struct {
+	tree   *diskTree
+	values Codec[V]
+}
+
+// Entry – пара ключ–значение, которую выдают итераторы BPlusTreeMap.
+type Entry[K constraints.Ordered, V any] struct {
+	Key   K
+	Value V
+}
+
+// Запись пары – кодировка ключа, за которой следует values.Encode(значение).
+
+// OpenMap открывает файл отображения или создаёт пустое, если файла нет или он пуст.
+func OpenMap[K constraints.Ordered, V any](path string, opts Options, values Codec[V]) (*BPlusTreeMap[K, V], error) {
+	tree, err := openDiskTree(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BPlusTreeMap[K, V]{tree: tree, values: values}, nil
+}
+
+// Put связывает ключ k со значением v. Если ключ уже был, возвращаются прежнее значение и true.
+func (m *BPlusTreeMap[K, V]) Put(k K, v V) (V, bool, error) {
+	s := spanOf(k)
+	old, replaced, err := m.remove(s)
+	if err != nil {
+		return old, false, err
+	}
+	_, err = m.tree.insertEntry(s.lo + string(m.values.Encode(v)))
+	return old, replaced, err
+}
+
+// Get возвращает значение ключа k и признак его наличия.
+func (m *BPlusTreeMap[K, V]) Get(k K) (V, bool, error) {
+	var v V
+	e, found, err := m.tree.entryOf(spanOf(k))
+	if err != nil || !found {
+		return v, false, err
+	}
+	entry, err := m.decode(e)
+	return entry.Value, err == nil, err
+}
+
+// Delete удаляет ключ k и возвращает его значение и признак того, что ключ был.
+func (m *BPlusTreeMap[K, V]) Delete(k K) (V, bool, error) {
+	return m.remove(spanOf(k))
+}
+
+// Len возвращает число ключей.
+func (m *BPlusTreeMap[K, V]) Len() int {
+	return m.tree.len()
+}
+
+// Sync записывает все изменения в файл и дожидается их сохранения на диске.
+// Журнала нет: сбой во время Sync может повредить файл.
+func (m *BPlusTreeMap[K, V]) Sync() error {
+	return m.tree.sync()
+}
+
+// Close сохраняет изменения и закрывает файл. После Close отображение использовать нельзя.
+func (m *BPlusTreeMap[K, V]) Close() error {
+	return m.tree.close()
+}
+
+// remove удаляет запись ключа s и возвращает его значение и признак того, что ключ был.
+func (m *BPlusTreeMap[K, V]) remove(s span) (V, bool, error) {
+	var v V
+	e, found, err := m.tree.entryOf(s)
+	if err != nil || !found {
+		return v, false, err
+	}
+	entry, err := m.decode(e)
+	if err != nil {
+		return v, false, err
+	}
+	if _, err := m.tree.deleteEntry(e); err != nil {
+		return v, false, err
+	}
+	return entry.Value, true, nil
+}
+
+// decode раскодирует запись пары.
+func (m *BPlusTreeMap[K, V]) decode(e string) (Entry[K, V], error) {
+	k, rest, err := decodeKey[K]([]byte(e))
+	if err != nil {
+		return Entry[K, V]{}, err
+	}
+	v, err := m.values.Decode(rest)
+	return Entry[K, V]{Key: k, Value: v}, err
+}
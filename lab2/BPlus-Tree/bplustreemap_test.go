@@ -0,0 +1,179 @@
+package BPlus_Tree
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	B_Tree "btree-example/B-Tree"
+)
+
+// stringCodec хранит строковые значения без преобразования.
+type stringCodec struct{}
+
+func (stringCodec) Encode(v string) []byte             { return []byte(v) }
+func (stringCodec) Decode(data []byte) (string, error) { return string(data), nil }
+
+func openMap[K string | int](tb testing.TB, path string) *BPlusTreeMap[K, string] {
+	tb.Helper()
+	m, err := OpenMap[K, string](path, smallOptions, stringCodec{})
+	if err != nil {
+		tb.Fatalf("OpenMap: %v", err)
+	}
+	return m
+}
+
+func TestBPlusTreeMap_PutGetDelete(t *testing.T) {
+	m := openMap[string](t, filepath.Join(t.TempDir(), "map.db"))
+	defer m.Close()
+
+	if _, replaced, err := m.Put("a", "1"); err != nil || replaced {
+		t.Errorf("Put(a) on empty map = (%v, %v)", replaced, err)
+	}
+	if _, _, err := m.Put("b", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if old, replaced, err := m.Put("a", "10"); err != nil || !replaced || old != "1" {
+		t.Errorf("Put(a) again: expected old value 1, got %q (%v, %v)", old, replaced, err)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %d", m.Len())
+	}
+	if v, ok, err := m.Get("a"); err != nil || !ok || v != "10" {
+		t.Errorf("Get(a): expected 10, got %q (%v, %v)", v, ok, err)
+	}
+	if _, ok, err := m.Get("c"); err != nil || ok {
+		t.Errorf("Get(c) = (%v, %v), want missing", ok, err)
+	}
+
+	if old, ok, err := m.Delete("b"); err != nil || !ok || old != "2" {
+		t.Errorf("Delete(b): expected 2, got %q (%v, %v)", old, ok, err)
+	}
+	if _, ok, err := m.Delete("b"); err != nil || ok {
+		t.Errorf("Delete(b) twice = (%v, %v)", ok, err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Expected 1 key, got %d", m.Len())
+	}
+}
+
+// TestBPlusTreeMap_Random сравнивает BPlusTreeMap со встроенной map на случайных операциях
+// с длинными значениями, а затем проверяет обходы и порядковые статистики после Close и Open
+func TestBPlusTreeMap_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	path := filepath.Join(t.TempDir(), "map.db")
+	m := openMap[int](t, path)
+	model := make(map[int]string)
+
+	for op := 0; op < 3000; op++ {
+		k := rng.Intn(300) - 150
+		switch rng.Intn(3) {
+		case 0, 1:
+			v := fmt.Sprintf("%d:%s", op, strings.Repeat("v", rng.Intn(400)))
+			old, replaced, err := m.Put(k, v)
+			want, exists := model[k]
+			if err != nil || replaced != exists || old != want {
+				t.Fatalf("Put(%d): got (%.10q, %v, %v), want (%.10q, %v)", k, old, replaced, err, want, exists)
+			}
+			model[k] = v
+		case 2:
+			old, found, err := m.Delete(k)
+			want, exists := model[k]
+			if err != nil || found != exists || old != want {
+				t.Fatalf("Delete(%d): got (%.10q, %v, %v), want (%.10q, %v)", k, old, found, err, want, exists)
+			}
+			delete(model, k)
+		}
+		if m.Len() != len(model) {
+			t.Fatalf("op %d: Len %d, want %d", op, m.Len(), len(model))
+		}
+	}
+	checkInvariants(t, m.tree)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m = openMap[int](t, path)
+	defer m.Close()
+	for k, want := range model {
+		if v, ok, err := m.Get(k); err != nil || !ok || v != want {
+			t.Fatalf("Get(%d) after reopen: got (%.10q, %v, %v), want %.10q", k, v, ok, err, want)
+		}
+	}
+	keys := make([]int, 0, len(model))
+	for k := range model {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var got []int
+	for e, err := range m.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Value != model[e.Key] {
+			t.Fatalf("All yielded value %.10q for key %d, want %.10q", e.Value, e.Key, model[e.Key])
+		}
+		got = append(got, e.Key)
+	}
+	if !slices.Equal(got, keys) {
+		t.Fatalf("All returned %d keys, want %d", len(got), len(keys))
+	}
+
+	for i, want := range keys {
+		if k, v, ok, err := m.Select(i); err != nil || !ok || k != want || v != model[want] {
+			t.Fatalf("Select(%d) = (%d, %v, %v), want %d", i, k, ok, err, want)
+		}
+	}
+	for q := -160; q <= 160; q += 7 {
+		want := 0
+		for _, k := range keys {
+			if k < q {
+				want++
+			}
+		}
+		if r, err := m.Rank(q); err != nil || r != want {
+			t.Fatalf("Rank(%d) = (%d, %v), want %d", q, r, err, want)
+		}
+		k, v, ok, err := m.Ceiling(q)
+		wantOK := want < len(keys)
+		if err != nil || ok != wantOK || ok && (k != keys[want] || v != model[k]) {
+			t.Fatalf("Ceiling(%d) = (%d, %v, %v)", q, k, ok, err)
+		}
+	}
+	if n, err := m.CountRange(-50, 50, B_Tree.ClosedOpen); err != nil {
+		t.Fatal(err)
+	} else if want := len(slices.DeleteFunc(slices.Clone(keys), func(k int) bool { return k < -50 || k >= 50 })); n != want {
+		t.Errorf("CountRange(-50, 50) = %d, want %d", n, want)
+	}
+}
+
+func TestBPlusTreeMap_Prefix(t *testing.T) {
+	m := openMap[string](t, filepath.Join(t.TempDir(), "map.db"))
+	defer m.Close()
+	for _, w := range []string{"car", "card", "cat", "ca\x00r", "dog"} {
+		if _, _, err := m.Put(w, strings.ToUpper(w)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for e, err := range MapPrefix(m, "car") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Value != strings.ToUpper(e.Key) {
+			t.Errorf("MapPrefix yielded %q for key %q", e.Value, e.Key)
+		}
+		got = append(got, e.Key)
+	}
+	if want := []string{"car", "card"}; !slices.Equal(got, want) {
+		t.Errorf("MapPrefix(car) = %q, want %q", got, want)
+	}
+	if k, v, ok, err := m.Max(); err != nil || !ok || k != "dog" || v != "DOG" {
+		t.Errorf("Max = (%q, %q, %v, %v), want dog", k, v, ok, err)
+	}
+}
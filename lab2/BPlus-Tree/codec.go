@@ -0,0 +1,151 @@
+package BPlus_Tree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Записи в файле – байтовые строки. Ключ кодируется так, что порядок байтов совпадает
+// с порядком ключей и ни одна кодировка не является префиксом другой:
+//
+//	строки – байты, где 0x00 заменён на 0x00 0xFF, и завершающие 0x00 0x01
+//	целые – 8 байт big-endian, у знаковых инвертирован старший бит
+//	числа с плавающей точкой – 8 байт float64, у отрицательных инвертированы все биты,
+//	у остальных – знаковый; -0 кодируется как 0, NaN не поддерживается
+//
+// Поэтому все записи ключа k начинаются с его кодировки, а записи других ключей
+// лежат целиком до или после них, что бы ни было дописано после ключа.
+
+// Codec переводит значения отображения в байты и обратно.
+type Codec[V any] interface {
+	Encode(v V) []byte
+	Decode(data []byte) (V, error)
+}
+
+// orderedCodec кодирует значения упорядоченных типов так же, как ключи.
+type orderedCodec[V constraints.Ordered] struct{}
+
+// OrderedCodec возвращает Codec для строк и чисел.
+func OrderedCodec[V constraints.Ordered]() Codec[V] { return orderedCodec[V]{} }
+
+func (orderedCodec[V]) Encode(v V) []byte { return appendKey(nil, v) }
+
+func (orderedCodec[V]) Decode(data []byte) (V, error) {
+	v, rest, err := decodeKey[V](data)
+	if err == nil && len(rest) != 0 {
+		err = fmt.Errorf("%w: %d extra bytes after value", ErrCorrupted, len(rest))
+	}
+	return v, err
+}
+
+// appendKey дописывает к dst кодировку ключа k.
+func appendKey[K constraints.Ordered](dst []byte, k K) []byte {
+	v := reflect.ValueOf(k)
+	switch v.Kind() {
+	case reflect.String:
+		return append(appendEscaped(dst, v.String()), 0x00, 0x01)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.BigEndian.AppendUint64(dst, uint64(v.Int())^1<<63)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.BigEndian.AppendUint64(dst, v.Uint())
+	default: // Float32, Float64
+		f := v.Float()
+		if f == 0 {
+			f = 0 // -0 == 0, у них должна быть одна кодировка
+		}
+		bits := math.Float64bits(f)
+		if bits>>63 == 1 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		return binary.BigEndian.AppendUint64(dst, bits)
+	}
+}
+
+// appendEscaped дописывает байты s с заменой 0x00 на 0x00 0xFF, без завершения.
+// Экранированный префикс строки – префикс экранированной строки, поэтому Prefix
+// обходит записи, начинающиеся с appendEscaped(prefix).
+func appendEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		dst = append(dst, s[i])
+		if s[i] == 0x00 {
+			dst = append(dst, 0xff)
+		}
+	}
+	return dst
+}
+
+// decodeKey раскодирует ключ в начале data и возвращает остаток записи.
+func decodeKey[K constraints.Ordered](data []byte) (K, []byte, error) {
+	var k K
+	v := reflect.ValueOf(&k).Elem()
+	if v.Kind() == reflect.String {
+		s := make([]byte, 0, len(data))
+		for i := 0; i+1 < len(data); i++ {
+			if data[i] != 0x00 {
+				s = append(s, data[i])
+				continue
+			}
+			switch data[i+1] {
+			case 0xff:
+				s = append(s, 0x00)
+				i++
+			case 0x01:
+				v.SetString(string(s))
+				return k, data[i+2:], nil
+			default:
+				return k, nil, fmt.Errorf("%w: invalid escape in string key", ErrCorrupted)
+			}
+		}
+		return k, nil, fmt.Errorf("%w: unterminated string key", ErrCorrupted)
+	}
+
+	if len(data) < 8 {
+		return k, nil, fmt.Errorf("%w: numeric key has %d bytes", ErrCorrupted, len(data))
+	}
+	bits := binary.BigEndian.Uint64(data)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(bits ^ 1<<63))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(bits)
+	default:
+		if bits>>63 == 1 {
+			bits &^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		v.SetFloat(math.Float64frombits(bits))
+	}
+	return k, data[8:], nil
+}
+
+// span – записи одного ключа: все они не меньше lo и меньше hi, а записи больших
+// ключей не меньше hi. hi == nil, если больших ключей не бывает (наибольшее целое).
+type span struct {
+	lo string
+	hi *string
+}
+
+func spanOf[K constraints.Ordered](k K) span {
+	lo := appendKey(nil, k)
+	// hi – наименьшая строка больше всех строк с префиксом lo: последний байт,
+	// меньший 0xFF, увеличивается, а всё после него отбрасывается.
+	for i := len(lo) - 1; i >= 0; i-- {
+		if lo[i] != 0xff {
+			hi := string(append(lo[:i:i], lo[i]+1))
+			return span{lo: string(lo), hi: &hi}
+		}
+	}
+	return span{lo: string(lo)}
+}
+
+// holds сообщает, принадлежит ли запись e ключу этого span.
+func (s span) holds(e string) bool {
+	return len(e) >= len(s.lo) && e[:len(s.lo)] == s.lo
+}
@@ -0,0 +1,106 @@
+package BPlus_Tree
+
+import (
+	"bytes"
+	"cmp"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	B_Tree "btree-example/B-Tree"
+	"golang.org/x/exp/constraints"
+)
+
+// checkEncoding проверяет, что кодировки ключей упорядочены как сами ключи,
+// не являются префиксами друг друга и раскодируются обратно
+func checkEncoding[K constraints.Ordered](t *testing.T, keys []K) {
+	t.Helper()
+	for _, a := range keys {
+		ea := appendKey(nil, a)
+		got, rest, err := decodeKey[K](append(ea, 0x42))
+		if err != nil || got != a || !bytes.Equal(rest, []byte{0x42}) {
+			t.Fatalf("decodeKey(appendKey(%v)) = (%v, %v, %v)", a, got, rest, err)
+		}
+		for _, b := range keys {
+			eb := appendKey(nil, b)
+			if c := bytes.Compare(ea, eb); c != cmp.Compare(a, b) {
+				t.Fatalf("encodings of %v and %v compare as %d", a, b, c)
+			}
+			if a != b && bytes.HasPrefix(eb, ea) {
+				t.Fatalf("encoding of %v is a prefix of encoding of %v", a, b)
+			}
+			// Любая запись ключа a лежит внутри его span, записи других ключей – вне его.
+			s := spanOf(a)
+			entry := string(append(eb, 0xff, 0xff))
+			inside := entry >= s.lo && (s.hi == nil || entry < *s.hi)
+			if inside != (a == b) || s.holds(entry) != (a == b) {
+				t.Fatalf("entry of %v: inside span of %v = %v", b, a, inside)
+			}
+		}
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		checkEncoding(t, []string{"", "\x00", "\x00\x00", "\x00\x01", "\x00\xff", "\x01", "a", "a\x00", "a\x00b", "ab", "b", "\xff", "\xff\xff"})
+	})
+	t.Run("int", func(t *testing.T) {
+		checkEncoding(t, []int64{math.MinInt64, -1 << 40, -256, -1, 0, 1, 255, 256, 1 << 40, math.MaxInt64})
+	})
+	t.Run("uint", func(t *testing.T) {
+		checkEncoding(t, []uint64{0, 1, 255, 1 << 63, math.MaxUint64 - 1, math.MaxUint64})
+	})
+	t.Run("float", func(t *testing.T) {
+		checkEncoding(t, []float64{math.Inf(-1), -math.MaxFloat64, -1.5, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 0.25, 1, math.MaxFloat64, math.Inf(1)})
+		if !bytes.Equal(appendKey(nil, math.Copysign(0, -1)), appendKey(nil, 0.0)) {
+			t.Error("-0 and 0 have different encodings")
+		}
+	})
+	t.Run("named", func(t *testing.T) {
+		type id int16
+		checkEncoding(t, []id{-300, -1, 0, 7, 300})
+	})
+}
+
+// TestBPlusTree_IntKeys проверяет дерево с числовыми ключами, включая крайние значения
+func TestBPlusTree_IntKeys(t *testing.T) {
+	tree, err := Open[int64](filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	model := []int64{math.MinInt64, math.MaxInt64}
+	for i := int64(-200); i < 200; i += 3 {
+		model = append(model, i*i*i)
+	}
+	for _, k := range model {
+		if err := tree.Insert(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slices.Sort(model)
+
+	var got []int64
+	for k, err := range tree.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, model) {
+		t.Fatalf("All returned %d keys in wrong order", len(got))
+	}
+	if k, ok, err := tree.Floor(math.MaxInt64); err != nil || !ok || k != math.MaxInt64 {
+		t.Errorf("Floor(MaxInt64) = (%d, %v, %v)", k, ok, err)
+	}
+	if _, ok, err := tree.Successor(math.MaxInt64); err != nil || ok {
+		t.Errorf("Successor(MaxInt64) = (%v, %v), want none", ok, err)
+	}
+	if k, ok, err := tree.Predecessor(0); err != nil || !ok || k != -8 {
+		t.Errorf("Predecessor(0) = (%d, %v, %v), want -8", k, ok, err)
+	}
+	if n, err := tree.CountRange(-8, 8, B_Tree.Open); err != nil || n != 1 {
+		t.Errorf("CountRange(-8, 8, Open) = (%d, %v), want 1", n, err)
+	}
+}
@@ -0,0 +1,325 @@
+package BPlus_Tree
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+
+	B_Tree "btree-example/B-Tree"
+	"golang.org/x/exp/constraints"
+)
+
+// Итераторы возвращают пары (элемент, ошибка): при ошибке чтения файла выдаётся
+// нулевой элемент с ошибкой, и обход завершается.
+
+// ---------- ОБХОД ЛИСТЬЕВ ----------
+
+// ascend передаёт yield ключи по возрастанию, начиная с lo (с самого меньшего, если lo == nil),
+// пока yield возвращает true. Обход идёт по ссылкам между листьями; листьев не больше,
+// чем страниц, поэтому более длинная цепочка означает цикл в ссылках.
+func (t *diskTree) ascend(lo *string, inclusive bool, yield func(string) bool) error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	n, err := t.findLeaf(lo, false)
+	if err != nil {
+		return err
+	}
+	i := 0
+	if lo != nil {
+		// Первый ключ листа, не меньший lo (больший lo, если граница не входит).
+		if i, err = t.search(n, *lo, !inclusive); err != nil {
+			return err
+		}
+	}
+	for leaves := 1; ; leaves++ {
+		for ; i < len(n.keys); i++ {
+			k, err := t.loadKey(n.keys[i])
+			if err != nil {
+				return err
+			}
+			if !yield(k) {
+				return nil
+			}
+		}
+		if n.next == 0 {
+			return nil
+		}
+		if leaves >= int(t.p.pages) {
+			return fmt.Errorf("%w: leaf links form a cycle", ErrCorrupted)
+		}
+		if n, err = t.readNode(n.next); err != nil {
+			return err
+		}
+		i = 0
+	}
+}
+
+// descend передаёт yield ключи по убыванию, начиная с hi (с самого большего, если hi == nil),
+// пока yield возвращает true.
+func (t *diskTree) descend(hi *string, inclusive bool, yield func(string) bool) error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	n, err := t.findLeaf(hi, true)
+	if err != nil {
+		return err
+	}
+	// i – число ключей листа, не больших hi (меньших hi, если граница не входит).
+	i := len(n.keys)
+	if hi != nil {
+		if i, err = t.search(n, *hi, inclusive); err != nil {
+			return err
+		}
+	}
+	for leaves := 1; ; leaves++ {
+		for i--; i >= 0; i-- {
+			k, err := t.loadKey(n.keys[i])
+			if err != nil {
+				return err
+			}
+			if !yield(k) {
+				return nil
+			}
+		}
+		if n.prev == 0 {
+			return nil
+		}
+		if leaves >= int(t.p.pages) {
+			return fmt.Errorf("%w: leaf links form a cycle", ErrCorrupted)
+		}
+		if n, err = t.readNode(n.prev); err != nil {
+			return err
+		}
+		i = len(n.keys)
+	}
+}
+
+// ---------- ОБХОДЫ ЗАПИСЕЙ ----------
+
+// walk – обход записей дерева: передаёт их yield, пока тот возвращает true.
+type walk func(yield func(string) bool) error
+
+// all обходит все записи по возрастанию или, если backward, по убыванию.
+func (t *diskTree) all(backward bool) walk {
+	return func(yield func(string) bool) error {
+		if backward {
+			return t.descend(nil, false, yield)
+		}
+		return t.ascend(nil, false, yield)
+	}
+}
+
+// upFrom обходит записи по возрастанию с первой записи ключа s или, если after,
+// с первой записи после них.
+func (t *diskTree) upFrom(s span, after bool) walk {
+	return func(yield func(string) bool) error {
+		switch {
+		case !after:
+			return t.ascend(&s.lo, true, yield)
+		case s.hi != nil:
+			return t.ascend(s.hi, true, yield)
+		case t.p == nil:
+			return ErrClosed
+		}
+		return nil
+	}
+}
+
+// downFrom обходит записи по убыванию с последней записи ключа s или, если не through,
+// с последней записи перед ними.
+func (t *diskTree) downFrom(s span, through bool) walk {
+	return func(yield func(string) bool) error {
+		if through {
+			return t.descend(s.hi, false, yield)
+		}
+		return t.descend(&s.lo, false, yield)
+	}
+}
+
+// between обходит по возрастанию записи ключей между lo и hi; bounds задаёт, входят ли границы.
+func (t *diskTree) between(lo, hi span, bounds B_Tree.Bounds) walk {
+	end := &hi.lo
+	if bounds.HiInclusive() {
+		end = hi.hi
+	}
+	return func(yield func(string) bool) error {
+		return t.upFrom(lo, !bounds.LoInclusive())(func(e string) bool {
+			return (end == nil || e < *end) && yield(e)
+		})
+	}
+}
+
+// withPrefix обходит по возрастанию записи строковых ключей, начинающихся с prefix.
+func (t *diskTree) withPrefix(prefix string) walk {
+	p := string(appendEscaped(nil, prefix))
+	return func(yield func(string) bool) error {
+		return t.ascend(&p, true, func(e string) bool {
+			return strings.HasPrefix(e, p) && yield(e)
+		})
+	}
+}
+
+// first возвращает первую запись обхода.
+func first(w walk) (string, bool, error) {
+	var entry string
+	found := false
+	err := w(func(e string) bool {
+		entry, found = e, true
+		return false
+	})
+	return entry, found, err
+}
+
+// seq превращает обход в итератор элементов, раскодированных decode; ошибка обхода
+// или раскодирования передаётся последним элементом.
+func seq[E any](w walk, decode func(string) (E, error)) iter.Seq2[E, error] {
+	return func(yield func(E, error) bool) {
+		var decodeErr error
+		err := w(func(e string) bool {
+			v, err := decode(e)
+			if err != nil {
+				decodeErr = err
+				return false
+			}
+			return yield(v, nil)
+		})
+		if err == nil {
+			err = decodeErr
+		}
+		if err != nil {
+			var zero E
+			yield(zero, err)
+		}
+	}
+}
+
+// firstOf возвращает первый элемент обхода, раскодированный decode.
+func firstOf[E any](w walk, decode func(string) (E, error)) (E, bool, error) {
+	var v E
+	e, found, err := first(w)
+	if err != nil || !found {
+		return v, false, err
+	}
+	v, err = decode(e)
+	return v, err == nil, err
+}
+
+// decodeOccurrence возвращает ключ записи-вхождения BPlusTree.
+func decodeOccurrence[T constraints.Ordered](e string) (T, error) {
+	k, _, err := decodeKey[T]([]byte(e))
+	return k, err
+}
+
+// ---------- ИТЕРАТОРЫ ----------
+
+// All обходит ключи по возрастанию. Изменять дерево во время обхода нельзя –
+// это относится ко всем итераторам пакета.
+func (t *BPlusTree[T]) All() iter.Seq2[T, error] {
+	return seq(t.tree.all(false), decodeOccurrence[T])
+}
+
+// Backward обходит ключи по убыванию.
+func (t *BPlusTree[T]) Backward() iter.Seq2[T, error] {
+	return seq(t.tree.all(true), decodeOccurrence[T])
+}
+
+// Range обходит по возрастанию ключи между lo и hi; bounds задаёт, входят ли границы.
+func (t *BPlusTree[T]) Range(lo, hi T, bounds B_Tree.Bounds) iter.Seq2[T, error] {
+	return seq(t.tree.between(spanOf(lo), spanOf(hi), bounds), decodeOccurrence[T])
+}
+
+// Prefix обходит по возрастанию строки дерева, начинающиеся с prefix.
+func Prefix(t *BPlusTree[string], prefix string) iter.Seq2[string, error] {
+	return seq(t.tree.withPrefix(prefix), decodeOccurrence[string])
+}
+
+// ---------- СОСЕДИ ----------
+
+// Min возвращает наименьший ключ; false, если дерево пусто.
+func (t *BPlusTree[T]) Min() (T, bool, error) {
+	return firstOf(t.tree.all(false), decodeOccurrence[T])
+}
+
+// Max возвращает наибольший ключ; false, если дерево пусто.
+func (t *BPlusTree[T]) Max() (T, bool, error) {
+	return firstOf(t.tree.all(true), decodeOccurrence[T])
+}
+
+// Floor возвращает наибольший ключ, не больший k.
+func (t *BPlusTree[T]) Floor(k T) (T, bool, error) {
+	return firstOf(t.tree.downFrom(spanOf(k), true), decodeOccurrence[T])
+}
+
+// Ceiling возвращает наименьший ключ, не меньший k.
+func (t *BPlusTree[T]) Ceiling(k T) (T, bool, error) {
+	return firstOf(t.tree.upFrom(spanOf(k), false), decodeOccurrence[T])
+}
+
+// Predecessor возвращает наибольший ключ, строго меньший k.
+func (t *BPlusTree[T]) Predecessor(k T) (T, bool, error) {
+	return firstOf(t.tree.downFrom(spanOf(k), false), decodeOccurrence[T])
+}
+
+// Successor возвращает наименьший ключ, строго больший k.
+func (t *BPlusTree[T]) Successor(k T) (T, bool, error) {
+	return firstOf(t.tree.upFrom(spanOf(k), true), decodeOccurrence[T])
+}
+
+// ---------- BPlusTreeMap ----------
+
+// All обходит пары по возрастанию ключей.
+func (m *BPlusTreeMap[K, V]) All() iter.Seq2[Entry[K, V], error] {
+	return seq(m.tree.all(false), m.decode)
+}
+
+// Backward обходит пары по убыванию ключей.
+func (m *BPlusTreeMap[K, V]) Backward() iter.Seq2[Entry[K, V], error] {
+	return seq(m.tree.all(true), m.decode)
+}
+
+// Range обходит по возрастанию пары с ключами между lo и hi; bounds задаёт, входят ли границы.
+func (m *BPlusTreeMap[K, V]) Range(lo, hi K, bounds B_Tree.Bounds) iter.Seq2[Entry[K, V], error] {
+	return seq(m.tree.between(spanOf(lo), spanOf(hi), bounds), m.decode)
+}
+
+// MapPrefix обходит по возрастанию пары отображения, ключи которых начинаются с prefix.
+func MapPrefix[V any](m *BPlusTreeMap[string, V], prefix string) iter.Seq2[Entry[string, V], error] {
+	return seq(m.tree.withPrefix(prefix), m.decode)
+}
+
+// neighbor возвращает первую пару обхода w.
+func (m *BPlusTreeMap[K, V]) neighbor(w walk) (K, V, bool, error) {
+	e, found, err := firstOf(w, m.decode)
+	return e.Key, e.Value, found, err
+}
+
+// Min возвращает пару с наименьшим ключом; false, если отображение пусто.
+func (m *BPlusTreeMap[K, V]) Min() (K, V, bool, error) {
+	return m.neighbor(m.tree.all(false))
+}
+
+// Max возвращает пару с наибольшим ключом; false, если отображение пусто.
+func (m *BPlusTreeMap[K, V]) Max() (K, V, bool, error) {
+	return m.neighbor(m.tree.all(true))
+}
+
+// Floor возвращает пару с наибольшим ключом, не большим k.
+func (m *BPlusTreeMap[K, V]) Floor(k K) (K, V, bool, error) {
+	return m.neighbor(m.tree.downFrom(spanOf(k), true))
+}
+
+// Ceiling возвращает пару с наименьшим ключом, не меньшим k.
+func (m *BPlusTreeMap[K, V]) Ceiling(k K) (K, V, bool, error) {
+	return m.neighbor(m.tree.upFrom(spanOf(k), false))
+}
+
+// Predecessor возвращает пару с наибольшим ключом, строго меньшим k.
+func (m *BPlusTreeMap[K, V]) Predecessor(k K) (K, V, bool, error) {
+	return m.neighbor(m.tree.downFrom(spanOf(k), false))
+}
+
+// Successor возвращает пару с наименьшим ключом, строго большим k.
+func (m *BPlusTreeMap[K, V]) Successor(k K) (K, V, bool, error) {
+	return m.neighbor(m.tree.upFrom(spanOf(k), true))
+}
@@ -0,0 +1,152 @@
+package BPlus_Tree
+
+import (
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	B_Tree "btree-example/B-Tree"
+)
+
+var allBounds = []B_Tree.Bounds{B_Tree.Closed, B_Tree.ClosedOpen, B_Tree.OpenClosed, B_Tree.Open}
+
+func inRange(k, lo, hi string, bounds B_Tree.Bounds) bool {
+	return (k > lo || bounds.LoInclusive() && k == lo) && (k < hi || bounds.HiInclusive() && k == hi)
+}
+
+// TestBPlusTree_Iterators сравнивает обходы и запросы соседей с отсортированным срезом
+func TestBPlusTree_Iterators(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+	var model, queries []string
+	for _, v := range rng.Perm(600) {
+		queries = append(queries, testKey(v))
+		if v%4 != 0 { // пропуски, чтобы запросы попадали между ключами
+			if err := tree.Insert(testKey(v)); err != nil {
+				t.Fatal(err)
+			}
+			model = append(model, testKey(v))
+		}
+	}
+	slices.Sort(model)
+	queries = append(queries, "", "a", "key/", "long/", "z")
+
+	if got := collect(t, tree.All()); !slices.Equal(got, model) {
+		t.Fatal("All differs from model")
+	}
+	backward := slices.Clone(model)
+	slices.Reverse(backward)
+	if got := collect(t, tree.Backward()); !slices.Equal(got, backward) {
+		t.Fatal("Backward differs from model")
+	}
+	if k, _, err := tree.Min(); err != nil || k != model[0] {
+		t.Errorf("Min = (%q, %v), want %q", k, err, model[0])
+	}
+	if k, _, err := tree.Max(); err != nil || k != model[len(model)-1] {
+		t.Errorf("Max = (%q, %v), want %q", k, err, model[len(model)-1])
+	}
+
+	for _, q := range queries {
+		checkNeighbor(t, "Floor", q, tree.Floor, model, func(k string) bool { return k <= q }, true)
+		checkNeighbor(t, "Predecessor", q, tree.Predecessor, model, func(k string) bool { return k < q }, true)
+		checkNeighbor(t, "Ceiling", q, tree.Ceiling, model, func(k string) bool { return k >= q }, false)
+		checkNeighbor(t, "Successor", q, tree.Successor, model, func(k string) bool { return k > q }, false)
+	}
+
+	for i := 0; i < 100; i++ {
+		lo, hi := queries[rng.Intn(len(queries))], queries[rng.Intn(len(queries))]
+		for _, bounds := range allBounds {
+			var want []string
+			for _, k := range model {
+				if inRange(k, lo, hi, bounds) {
+					want = append(want, k)
+				}
+			}
+			if got := collect(t, tree.Range(lo, hi, bounds)); !slices.Equal(got, want) {
+				t.Fatalf("Range(%q, %q, %d) returned %d keys, want %d", lo, hi, bounds, len(got), len(want))
+			}
+		}
+	}
+}
+
+// checkNeighbor сравнивает запрос соседа с линейным поиском в model: last выбирает
+// наибольший подходящий ключ, иначе наименьший.
+func checkNeighbor(t *testing.T, name, q string, query func(string) (string, bool, error), model []string, match func(string) bool, last bool) {
+	t.Helper()
+	want, exists := "", false
+	for _, k := range model {
+		if match(k) && (!exists || last) {
+			want, exists = k, true
+		}
+	}
+	got, ok, err := query(q)
+	if err != nil {
+		t.Fatalf("%s(%q): %v", name, q, err)
+	}
+	if ok != exists || got != want {
+		t.Fatalf("%s(%q) = (%q, %v), want (%q, %v)", name, q, got, ok, want, exists)
+	}
+}
+
+func TestBPlusTree_IteratorBreak(t *testing.T) {
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+
+	if _, ok, err := tree.Min(); ok || err != nil {
+		t.Errorf("Min of empty tree = (%v, %v)", ok, err)
+	}
+	if got := collect(t, tree.Range("a", "z", B_Tree.Closed)); len(got) != 0 {
+		t.Errorf("Range of empty tree returned %v", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := tree.Insert(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count := 0
+	for _, err := range tree.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count++; count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Errorf("Expected to stop after 5 keys, got %d", count)
+	}
+
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range tree.All() {
+		if err != ErrClosed {
+			t.Errorf("All after Close yielded %v, want ErrClosed", err)
+		}
+	}
+}
+
+func TestBPlusTree_Prefix(t *testing.T) {
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+	words := []string{"car", "card", "care", "cargo", "cat", "ca", "c", "dog", "carb"}
+	for _, w := range words {
+		if err := tree.Insert(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"car", "carb", "card", "care", "cargo"}
+	if got := collect(t, Prefix(tree, "car")); !slices.Equal(got, want) {
+		t.Errorf("Prefix(car) = %v, want %v", got, want)
+	}
+	if got := collect(t, Prefix(tree, "x")); len(got) != 0 {
+		t.Errorf("Prefix(x) = %v, want none", got)
+	}
+	if got := collect(t, Prefix(tree, "")); len(got) != len(words) {
+		t.Errorf("Prefix(\"\") returned %d keys, want %d", len(got), len(words))
+	}
+}
@@ -0,0 +1,291 @@
+package BPlus_Tree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Типы страниц (первый байт страницы).
+const (
+	pageLeaf     = 1
+	pageInternal = 2
+	pageOverflow = 3
+	pageFree     = 4
+)
+
+// Заголовок страницы узла:
+//
+//	0 тип (u8)   2 число ключей (u16)   4 предыдущий лист (u32)   8 следующий лист (u32)
+//
+// В листе за заголовком идут слоты ключей. Во внутреннем узле сначала идут
+// internalCap+1 записей о детях (номер страницы u32, число ключей в поддереве u32), затем слоты ключей.
+// Слот ключа: длина (u32), первая страница продолжения (u32, 0 – нет), первые inlineKey байт.
+// Страница продолжения: тип (u8), следующая страница (u32 по смещению 4), данные с 8 байта.
+const (
+	nodeHeader     = 16
+	childEntry     = 8
+	overflowHeader = 8
+)
+
+// key – ключ в слоте узла. Если ключ длиннее встроенной части, его продолжение
+// хранится в цепочке страниц, начиная с overflow.
+type key struct {
+	length   int
+	prefix   string // первые min(length, inlineKey) байт
+	overflow uint32
+}
+
+// node – раскодированная страница узла.
+type node struct {
+	id       uint32
+	leaf     bool
+	keys     []key
+	children []uint32 // только во внутренних узлах
+	counts   []uint32 // число ключей в поддереве каждого ребёнка
+	prev     uint32   // соседние листья; 0 – нет
+	next     uint32
+}
+
+// size возвращает число ключей в поддереве узла.
+func (n *node) size() int {
+	if n.leaf {
+		return len(n.keys)
+	}
+	total := 0
+	for _, c := range n.counts {
+		total += int(c)
+	}
+	return total
+}
+
+func (t *diskTree) keySlot() int { return 8 + t.p.inlineKey }
+
+// ---------- ЧТЕНИЕ И ЗАПИСЬ УЗЛОВ ----------
+
+func (t *diskTree) readNode(id uint32) (*node, error) {
+	data, err := t.p.page(id)
+	if err != nil {
+		return nil, err
+	}
+	n := &node{id: id, leaf: data[0] == pageLeaf}
+	if data[0] != pageLeaf && data[0] != pageInternal {
+		return nil, fmt.Errorf("%w: page %d is not a node", ErrCorrupted, id)
+	}
+	count := int(binary.LittleEndian.Uint16(data[2:]))
+	limit := t.leafCap
+	if !n.leaf {
+		limit = t.internalCap
+	}
+	if count > limit {
+		return nil, fmt.Errorf("%w: node %d has %d keys", ErrCorrupted, id, count)
+	}
+
+	offset := nodeHeader
+	if n.leaf {
+		n.prev = binary.LittleEndian.Uint32(data[4:])
+		n.next = binary.LittleEndian.Uint32(data[8:])
+	} else {
+		n.children = make([]uint32, count+1)
+		n.counts = make([]uint32, count+1)
+		for i := range n.children {
+			n.children[i] = binary.LittleEndian.Uint32(data[offset+i*childEntry:])
+			n.counts[i] = binary.LittleEndian.Uint32(data[offset+i*childEntry+4:])
+		}
+		offset += (t.internalCap + 1) * childEntry
+	}
+
+	n.keys = make([]key, count)
+	for i := range n.keys {
+		slot := data[offset+i*t.keySlot():]
+		k := key{
+			length:   int(binary.LittleEndian.Uint32(slot)),
+			overflow: binary.LittleEndian.Uint32(slot[4:]),
+		}
+		if (k.overflow == 0) != (k.length <= t.p.inlineKey) {
+			return nil, fmt.Errorf("%w: key %d of node %d has length %d and overflow page %d",
+				ErrCorrupted, i, id, k.length, k.overflow)
+		}
+		k.prefix = string(slot[8 : 8+min(k.length, t.p.inlineKey)])
+		n.keys[i] = k
+	}
+	return n, nil
+}
+
+func (t *diskTree) writeNode(n *node) error {
+	data := make([]byte, t.p.pageSize)
+	offset := nodeHeader
+	binary.LittleEndian.PutUint16(data[2:], uint16(len(n.keys)))
+	if n.leaf {
+		data[0] = pageLeaf
+		binary.LittleEndian.PutUint32(data[4:], n.prev)
+		binary.LittleEndian.PutUint32(data[8:], n.next)
+	} else {
+		data[0] = pageInternal
+		for i, child := range n.children {
+			binary.LittleEndian.PutUint32(data[offset+i*childEntry:], child)
+			binary.LittleEndian.PutUint32(data[offset+i*childEntry+4:], n.counts[i])
+		}
+		offset += (t.internalCap + 1) * childEntry
+	}
+	for i, k := range n.keys {
+		slot := data[offset+i*t.keySlot():]
+		binary.LittleEndian.PutUint32(slot, uint32(k.length))
+		binary.LittleEndian.PutUint32(slot[4:], k.overflow)
+		copy(slot[8:], k.prefix)
+	}
+	return t.p.write(n.id, data)
+}
+
+// newNode выделяет страницу под новый узел. Узел попадает в файл при writeNode.
+func (t *diskTree) newNode(leaf bool) (*node, error) {
+	id, err := t.p.allocate()
+	if err != nil {
+		return nil, err
+	}
+	return &node{id: id, leaf: leaf}, nil
+}
+
+// ---------- КЛЮЧИ И СТРАНИЦЫ ПРОДОЛЖЕНИЯ ----------
+
+// makeKey создаёт слот для s, записывая его продолжение в новые страницы.
+func (t *diskTree) makeKey(s string) (key, error) {
+	k := key{length: len(s), prefix: s[:min(len(s), t.p.inlineKey)]}
+	rest := s[len(k.prefix):]
+	chunk := t.p.pageSize - overflowHeader
+	// Страницы записываются с конца, чтобы каждая знала номер следующей.
+	var next uint32
+	for end := len(rest); end > 0; {
+		start := (end - 1) / chunk * chunk
+		id, err := t.p.allocate()
+		if err != nil {
+			return key{}, err
+		}
+		data := make([]byte, t.p.pageSize)
+		data[0] = pageOverflow
+		binary.LittleEndian.PutUint32(data[4:], next)
+		copy(data[overflowHeader:], rest[start:end])
+		if err := t.p.write(id, data); err != nil {
+			return key{}, err
+		}
+		next, end = id, start
+	}
+	k.overflow = next
+	return k, nil
+}
+
+// chainPages возвращает число страниц продолжения ключа k. Длина, для которой
+// не хватило бы всех страниц файла, означает повреждённый слот.
+func (t *diskTree) chainPages(k key) (int, error) {
+	chunk := t.p.pageSize - overflowHeader
+	pages := (k.length - len(k.prefix) + chunk - 1) / chunk
+	if pages >= int(t.p.pages) {
+		return 0, fmt.Errorf("%w: key length %d exceeds the file size", ErrCorrupted, k.length)
+	}
+	return pages, nil
+}
+
+// loadKey собирает ключ целиком, читая его страницы продолжения.
+func (t *diskTree) loadKey(k key) (string, error) {
+	if k.overflow == 0 {
+		return k.prefix, nil
+	}
+	if _, err := t.chainPages(k); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.Grow(k.length)
+	b.WriteString(k.prefix)
+	for id := k.overflow; b.Len() < k.length; {
+		if id == 0 {
+			return "", fmt.Errorf("%w: overflow chain is too short", ErrCorrupted)
+		}
+		data, err := t.p.page(id)
+		if err != nil {
+			return "", err
+		}
+		if data[0] != pageOverflow {
+			return "", fmt.Errorf("%w: page %d is not an overflow page", ErrCorrupted, id)
+		}
+		n := min(k.length-b.Len(), len(data)-overflowHeader)
+		b.Write(data[overflowHeader : overflowHeader+n])
+		id = binary.LittleEndian.Uint32(data[4:])
+	}
+	return b.String(), nil
+}
+
+// freeKey освобождает страницы продолжения ключа.
+func (t *diskTree) freeKey(k key) error {
+	pages, err := t.chainPages(k)
+	if err != nil {
+		return err
+	}
+	for id := k.overflow; pages > 0; pages-- {
+		data, err := t.p.page(id)
+		if err != nil {
+			return err
+		}
+		if data[0] != pageOverflow {
+			return fmt.Errorf("%w: page %d is not an overflow page", ErrCorrupted, id)
+		}
+		next := binary.LittleEndian.Uint32(data[4:])
+		if err := t.p.free(id); err != nil {
+			return err
+		}
+		id = next
+	}
+	return nil
+}
+
+// copyKey возвращает копию ключа с собственной цепочкой продолжения –
+// так разделители во внутренних узлах не зависят от ключей листьев.
+func (t *diskTree) copyKey(k key) (key, error) {
+	if k.overflow == 0 {
+		return k, nil
+	}
+	s, err := t.loadKey(k)
+	if err != nil {
+		return key{}, err
+	}
+	return t.makeKey(s)
+}
+
+// compare сравнивает s с ключом слота. Страницы продолжения читаются,
+// только если s совпадает со встроенной частью ключа.
+func (t *diskTree) compare(s string, k key) (int, error) {
+	if k.overflow == 0 {
+		return strings.Compare(s, k.prefix), nil
+	}
+	if len(s) <= len(k.prefix) {
+		if c := strings.Compare(s, k.prefix); c != 0 {
+			return c, nil
+		}
+		return -1, nil // s – собственный префикс более длинного ключа
+	}
+	if c := strings.Compare(s[:len(k.prefix)], k.prefix); c != 0 {
+		return c, nil
+	}
+	full, err := t.loadKey(k)
+	if err != nil {
+		return 0, err
+	}
+	return strings.Compare(s, full), nil
+}
+
+// search возвращает число ключей узла, меньших s (не больших s, если inclusive).
+func (t *diskTree) search(n *node, s string, inclusive bool) (int, error) {
+	lo, hi := 0, len(n.keys)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		c, err := t.compare(s, n.keys[mid])
+		if err != nil {
+			return 0, err
+		}
+		if c > 0 || inclusive && c == 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,129 @@
+package BPlus_Tree
+
+import B_Tree "btree-example/B-Tree"
+
+// Порядковые статистики считаются по числу ключей в поддеревьях, которое внутренние узлы
+// хранят рядом с номерами детей, – без обхода листьев.
+
+// countBefore возвращает число записей, меньших k (не больших k, если inclusive).
+func (t *diskTree) countBefore(k string, inclusive bool) (int, error) {
+	if t.p == nil {
+		return 0, ErrClosed
+	}
+	count := 0
+	n, err := t.readNode(t.p.root)
+	for depth := 1; err == nil && !n.leaf; depth++ {
+		var i int
+		if i, err = t.search(n, k, true); err != nil {
+			break
+		}
+		// Все ключи детей левее i меньше разделителя n.keys[i-1] <= k.
+		for _, c := range n.counts[:i] {
+			count += int(c)
+		}
+		n, err = t.readNodeAt(n.children[i], depth)
+	}
+	if err != nil {
+		return 0, err
+	}
+	i, err := t.search(n, k, inclusive)
+	return count + i, err
+}
+
+// selectEntry возвращает запись с порядковым номером i (с нуля); false, если i вне [0, len()).
+func (t *diskTree) selectEntry(i int) (string, bool, error) {
+	if t.p == nil {
+		return "", false, ErrClosed
+	}
+	if i < 0 || i >= t.len() {
+		return "", false, nil
+	}
+	n, err := t.readNode(t.p.root)
+	for depth := 1; err == nil && !n.leaf; depth++ {
+		j := 0
+		for j < len(n.counts)-1 && i >= int(n.counts[j]) {
+			i -= int(n.counts[j])
+			j++
+		}
+		n, err = t.readNodeAt(n.children[j], depth)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if i >= len(n.keys) {
+		return "", false, ErrCorrupted
+	}
+	k, err := t.loadKey(n.keys[i])
+	return k, err == nil, err
+}
+
+// countKeys возвращает число записей ключей, меньших ключа s (не больших, если inclusive).
+func (t *diskTree) countKeys(s span, inclusive bool) (int, error) {
+	switch {
+	case !inclusive:
+		return t.countBefore(s.lo, false)
+	case s.hi != nil:
+		return t.countBefore(*s.hi, false)
+	case t.p == nil:
+		return 0, ErrClosed
+	}
+	return t.len(), nil
+}
+
+// countRange возвращает число записей ключей между lo и hi; bounds задаёт, входят ли границы.
+func (t *diskTree) countRange(lo, hi span, bounds B_Tree.Bounds) (int, error) {
+	below, err := t.countKeys(lo, !bounds.LoInclusive())
+	if err != nil {
+		return 0, err
+	}
+	upTo, err := t.countKeys(hi, bounds.HiInclusive())
+	if err != nil {
+		return 0, err
+	}
+	return max(upTo-below, 0), nil
+}
+
+// Rank возвращает число ключей, строго меньших k.
+func (t *BPlusTree[T]) Rank(k T) (int, error) {
+	return t.tree.countKeys(spanOf(k), false)
+}
+
+// Select возвращает ключ с порядковым номером i (с нуля); false, если i вне [0, Len()).
+func (t *BPlusTree[T]) Select(i int) (T, bool, error) {
+	var k T
+	e, found, err := t.tree.selectEntry(i)
+	if err != nil || !found {
+		return k, false, err
+	}
+	k, err = decodeOccurrence[T](e)
+	return k, err == nil, err
+}
+
+// CountRange возвращает число ключей между lo и hi; bounds задаёт, входят ли границы.
+func (t *BPlusTree[T]) CountRange(lo, hi T, bounds B_Tree.Bounds) (int, error) {
+	return t.tree.countRange(spanOf(lo), spanOf(hi), bounds)
+}
+
+// ---------- BPlusTreeMap ----------
+
+// Rank возвращает число ключей, строго меньших k.
+func (m *BPlusTreeMap[K, V]) Rank(k K) (int, error) {
+	return m.tree.countKeys(spanOf(k), false)
+}
+
+// Select возвращает пару с порядковым номером i (с нуля); false, если i вне [0, Len()).
+func (m *BPlusTreeMap[K, V]) Select(i int) (K, V, bool, error) {
+	e, found, err := m.tree.selectEntry(i)
+	if err != nil || !found {
+		var k K
+		var v V
+		return k, v, false, err
+	}
+	entry, err := m.decode(e)
+	return entry.Key, entry.Value, err == nil, err
+}
+
+// CountRange возвращает число ключей между lo и hi; bounds задаёт, входят ли границы.
+func (m *BPlusTreeMap[K, V]) CountRange(lo, hi K, bounds B_Tree.Bounds) (int, error) {
+	return m.tree.countRange(spanOf(lo), spanOf(hi), bounds)
+}
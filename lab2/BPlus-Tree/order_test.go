@@ -0,0 +1,68 @@
+package BPlus_Tree
+
+import (
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// TestBPlusTree_OrderStatistics сравнивает Rank, Select и CountRange с отсортированным срезом
+// на случайных вставках и удалениях с повторяющимися ключами
+func TestBPlusTree_OrderStatistics(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := openTree(t, filepath.Join(t.TempDir(), "tree.db"), smallOptions)
+	defer tree.Close()
+	var model []string
+
+	for op := 0; op < 2000; op++ {
+		k := testKey(rng.Intn(300))
+		i, found := slices.BinarySearch(model, k)
+		if rng.Intn(3) == 0 {
+			if _, err := tree.Delete(k); err != nil {
+				t.Fatal(err)
+			}
+			if found {
+				model = slices.Delete(model, i, i+1)
+			}
+		} else {
+			if err := tree.Insert(k); err != nil {
+				t.Fatal(err)
+			}
+			model = slices.Insert(model, i, k)
+		}
+		if op%100 != 0 {
+			continue
+		}
+
+		for i, want := range model {
+			if got, ok, err := tree.Select(i); err != nil || !ok || got != want {
+				t.Fatalf("Select(%d) = (%q, %v, %v), want %q", i, got, ok, err, want)
+			}
+		}
+		if _, ok, _ := tree.Select(len(model)); ok {
+			t.Fatal("Select(Len()) reported a key")
+		}
+		for q := 0; q <= 310; q += 7 {
+			k := testKey(q)
+			if got, err := tree.Rank(k); err != nil || got != sort.SearchStrings(model, k) {
+				t.Fatalf("Rank(%q) = (%d, %v), want %d", k, got, err, sort.SearchStrings(model, k))
+			}
+		}
+		for i := 0; i < 20; i++ {
+			lo, hi := testKey(rng.Intn(320)), testKey(rng.Intn(320))
+			for _, bounds := range allBounds {
+				want := 0
+				for _, k := range model {
+					if inRange(k, lo, hi, bounds) {
+						want++
+					}
+				}
+				if got, err := tree.CountRange(lo, hi, bounds); err != nil || got != want {
+					t.Fatalf("CountRange(%q, %q, %d) = (%d, %v), want %d", lo, hi, bounds, got, err, want)
+				}
+			}
+		}
+	}
+}
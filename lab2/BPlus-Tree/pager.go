@@ -0,0 +1,245 @@
+package BPlus_Tree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Страница 0 файла – метаданные, остальные – узлы, страницы продолжения ключей и свободные.
+// Все числа записываются в little-endian.
+//
+//	0  magic "BPTR"     8  размер страницы (u32)   16 корень (u32)          24 число ключей (u64)
+//	4  версия (u32)     12 встроенная длина ключа   20 число страниц (u32)   32 голова списка свободных
+const (
+	metaMagic   = "BPTR"
+	metaVersion = 1
+)
+
+var (
+	// ErrCorrupted – файл повреждён или имеет неизвестный формат.
+	ErrCorrupted = errors.New("corrupted B+Tree file")
+	// ErrIncompatible – файл создан с другим размером страницы или встроенной длиной ключа.
+	ErrIncompatible = errors.New("incompatible B+Tree file")
+	// ErrClosed – дерево уже закрыто.
+	ErrClosed = errors.New("B+Tree is closed")
+)
+
+// frame – страница, загруженная в буферный пул.
+type frame struct {
+	id    uint32
+	data  []byte
+	dirty bool
+	elem  *list.Element
+}
+
+// pager читает и пишет страницы файла через буферный пул из capacity страниц с вытеснением
+// давно не использованных (LRU). Изменённые страницы записываются в файл при вытеснении и в flush.
+type pager struct {
+	file     *os.File
+	pageSize int
+	capacity int
+	frames   map[uint32]*frame
+	lru      *list.List // от недавно использованных к давно использованным
+
+	// Поля метаданных; записываются на страницу 0 в flush.
+	inlineKey int
+	root      uint32
+	count     uint64
+	pages     uint32
+	freeHead  uint32
+}
+
+func openPager(path string, pageSize, capacity, inlineKey int) (*pager, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p := &pager{
+		file:      file,
+		pageSize:  pageSize,
+		capacity:  capacity,
+		frames:    make(map[uint32]*frame),
+		lru:       list.New(),
+		inlineKey: inlineKey,
+		pages:     1,
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() > 0 {
+		if err := p.readMeta(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *pager) readMeta() error {
+	meta := make([]byte, 40)
+	if _, err := p.file.ReadAt(meta, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrCorrupted
+		}
+		return err
+	}
+	if string(meta[0:4]) != metaMagic {
+		return ErrCorrupted
+	}
+	if version := binary.LittleEndian.Uint32(meta[4:]); version != metaVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrCorrupted, version)
+	}
+	pageSize := int(binary.LittleEndian.Uint32(meta[8:]))
+	inlineKey := int(binary.LittleEndian.Uint32(meta[12:]))
+	if pageSize != p.pageSize || inlineKey != p.inlineKey {
+		return fmt.Errorf("%w: file has page size %d and inline key %d, expected %d and %d",
+			ErrIncompatible, pageSize, inlineKey, p.pageSize, p.inlineKey)
+	}
+	p.root = binary.LittleEndian.Uint32(meta[16:])
+	p.pages = binary.LittleEndian.Uint32(meta[20:])
+	p.count = binary.LittleEndian.Uint64(meta[24:])
+	p.freeHead = binary.LittleEndian.Uint32(meta[32:])
+	if p.root == 0 || p.root >= p.pages || p.freeHead >= p.pages {
+		return fmt.Errorf("%w: invalid meta page", ErrCorrupted)
+	}
+	return nil
+}
+
+func (p *pager) writeMeta() error {
+	meta := make([]byte, p.pageSize)
+	copy(meta, metaMagic)
+	binary.LittleEndian.PutUint32(meta[4:], metaVersion)
+	binary.LittleEndian.PutUint32(meta[8:], uint32(p.pageSize))
+	binary.LittleEndian.PutUint32(meta[12:], uint32(p.inlineKey))
+	binary.LittleEndian.PutUint32(meta[16:], p.root)
+	binary.LittleEndian.PutUint32(meta[20:], p.pages)
+	binary.LittleEndian.PutUint64(meta[24:], p.count)
+	binary.LittleEndian.PutUint32(meta[32:], p.freeHead)
+	_, err := p.file.WriteAt(meta, 0)
+	return err
+}
+
+// page возвращает содержимое страницы id. Срез действителен до следующего обращения к pager.
+func (p *pager) page(id uint32) ([]byte, error) {
+	if id == 0 || id >= p.pages {
+		return nil, fmt.Errorf("%w: page %d out of range", ErrCorrupted, id)
+	}
+	if f, ok := p.frames[id]; ok {
+		p.lru.MoveToFront(f.elem)
+		return f.data, nil
+	}
+	f, err := p.newFrame(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.file.ReadAt(f.data, int64(id)*int64(p.pageSize)); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return f.data, nil
+}
+
+// write заменяет содержимое страницы id и помечает её изменённой.
+func (p *pager) write(id uint32, data []byte) error {
+	f, ok := p.frames[id]
+	if !ok {
+		var err error
+		if f, err = p.newFrame(id); err != nil {
+			return err
+		}
+	} else {
+		p.lru.MoveToFront(f.elem)
+	}
+	copy(f.data, data)
+	f.dirty = true
+	return nil
+}
+
+// newFrame выделяет в пуле место под страницу id, вытесняя давно не использованную.
+func (p *pager) newFrame(id uint32) (*frame, error) {
+	if len(p.frames) >= p.capacity {
+		victim := p.lru.Back().Value.(*frame)
+		if err := p.writeBack(victim); err != nil {
+			return nil, err
+		}
+		p.lru.Remove(victim.elem)
+		delete(p.frames, victim.id)
+	}
+	f := &frame{id: id, data: make([]byte, p.pageSize)}
+	f.elem = p.lru.PushFront(f)
+	p.frames[id] = f
+	return f, nil
+}
+
+func (p *pager) writeBack(f *frame) error {
+	if !f.dirty {
+		return nil
+	}
+	if _, err := p.file.WriteAt(f.data, int64(f.id)*int64(p.pageSize)); err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// allocate возвращает номер пустой страницы: из списка свободных или новой в конце файла.
+func (p *pager) allocate() (uint32, error) {
+	if p.freeHead == 0 {
+		id := p.pages
+		p.pages++
+		return id, nil
+	}
+	id := p.freeHead
+	data, err := p.page(id)
+	if err != nil {
+		return 0, err
+	}
+	if data[0] != pageFree {
+		return 0, fmt.Errorf("%w: page %d in free list is in use", ErrCorrupted, id)
+	}
+	p.freeHead = binary.LittleEndian.Uint32(data[4:])
+	return id, nil
+}
+
+// free возвращает страницу id в список свободных.
+func (p *pager) free(id uint32) error {
+	data := make([]byte, p.pageSize)
+	data[0] = pageFree
+	binary.LittleEndian.PutUint32(data[4:], p.freeHead)
+	p.freeHead = id
+	return p.write(id, data)
+}
+
+// flush записывает изменённые страницы и метаданные и сбрасывает файл на диск.
+// Файл согласован только после успешного завершения flush. Страницы перезаписываются
+// на месте (и при вытеснении из пула), а освобождённые используются повторно, поэтому
+// сбой во время flush или между вызовами может оставить файл в смеси старого и нового
+// состояний – порядок записи (страницы, затем метаданные) этого не предотвращает.
+func (p *pager) flush() error {
+	for _, f := range p.frames {
+		if err := p.writeBack(f); err != nil {
+			return err
+		}
+	}
+	if err := p.file.Sync(); err != nil {
+		return err
+	}
+	if err := p.writeMeta(); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+func (p *pager) close() error {
+	err := p.flush()
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
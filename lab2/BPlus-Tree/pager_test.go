@@ -0,0 +1,73 @@
+package BPlus_Tree
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// TestPager_Eviction проверяет, что пул держит не больше capacity страниц,
+// а вытесненные изменённые страницы записываются в файл и читаются обратно
+func TestPager_Eviction(t *testing.T) {
+	const pageSize, capacity, pages = 256, 4, 20
+	p, err := openPager(filepath.Join(t.TempDir(), "pages.db"), pageSize, capacity, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+
+	fill := func(id uint32) []byte { return bytes.Repeat([]byte{byte(id)}, pageSize) }
+	for i := 0; i < pages; i++ {
+		id, err := p.allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.write(id, fill(id)); err != nil {
+			t.Fatal(err)
+		}
+		if len(p.frames) > capacity {
+			t.Fatalf("pool holds %d pages, capacity is %d", len(p.frames), capacity)
+		}
+	}
+	for id := uint32(1); id <= pages; id++ {
+		data, err := p.page(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, fill(id)) {
+			t.Fatalf("page %d was not written back on eviction", id)
+		}
+	}
+	if _, err := p.page(pages + 1); err == nil {
+		t.Error("reading a page past the end of the file succeeded")
+	}
+}
+
+// TestPager_FreeList проверяет, что освобождённые страницы выдаются повторно
+func TestPager_FreeList(t *testing.T) {
+	p, err := openPager(filepath.Join(t.TempDir(), "pages.db"), 256, 4, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+
+	var ids []uint32
+	for i := 0; i < 3; i++ {
+		id, err := p.allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	for _, id := range ids[:2] {
+		if err := p.free(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Список свободных – стек: последняя освобождённая страница выдаётся первой.
+	for _, want := range []uint32{ids[1], ids[0], ids[2] + 1} {
+		if id, err := p.allocate(); err != nil || id != want {
+			t.Fatalf("allocate() = (%d, %v), want %d", id, err, want)
+		}
+	}
+}
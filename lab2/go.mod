@@ -1,5 +1,5 @@
 module btree-example
 
-go 1.23.2
+go 1.24
 
 require golang.org/x/exp v0.0.0-20250305212735-054e65f0b394